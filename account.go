@@ -0,0 +1,189 @@
+package notifox
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultBalanceCheckInterval is how often a BalanceWatcher checks the balance.
+const DefaultBalanceCheckInterval = time.Hour
+
+// Balance represents the account's remaining credit.
+type Balance struct {
+	Amount   float64 `json:"balance"`
+	Currency string  `json:"currency"`
+}
+
+// Usage represents the account's usage over a time range.
+type Usage struct {
+	From       time.Time        `json:"from"`
+	To         time.Time        `json:"to"`
+	Messages   int              `json:"messages"`
+	Parts      int              `json:"parts"`
+	Cost       float64          `json:"cost"`
+	Currency   string           `json:"currency"`
+	ByAudience []UsageBreakdown `json:"by_audience"`
+	ByChannel  []UsageBreakdown `json:"by_channel"`
+}
+
+// UsageBreakdown is the usage for a single audience or channel. Only the field
+// being broken down by is set.
+type UsageBreakdown struct {
+	Audience string  `json:"audience,omitempty"`
+	Channel  Channel `json:"channel,omitempty"`
+	Messages int     `json:"messages"`
+	Parts    int     `json:"parts"`
+	Cost     float64 `json:"cost"`
+	Currency string  `json:"currency"`
+}
+
+// GetBalance returns the account's remaining credit. It also confirms that the API key is accepted.
+func (c *Client) GetBalance(ctx context.Context) (*Balance, error) {
+	var balance Balance
+	if err := c.doWithRetry(ctx, http.MethodGet, "/account/balance", nil, &balance); err != nil {
+		return nil, err
+	}
+
+	return &balance, nil
+}
+
+// GetUsage returns the account's usage between from and to, broken down by
+// audience and by channel.
+func (c *Client) GetUsage(ctx context.Context, from, to time.Time) (*Usage, error) {
+	if !to.After(from) {
+		return nil, fmt.Errorf("usage range end must be after its start")
+	}
+
+	query := url.Values{}
+	query.Set("from", from.UTC().Format(time.RFC3339))
+	query.Set("to", to.UTC().Format(time.RFC3339))
+
+	var usage Usage
+	if err := c.doWithRetry(ctx, http.MethodGet, "/account/usage?"+query.Encode(), nil, &usage); err != nil {
+		return nil, err
+	}
+
+	return &usage, nil
+}
+
+// BalanceOption is a function that configures a BalanceWatcher.
+type BalanceOption func(*BalanceWatcher)
+
+// WithBalanceAudience sets the audience for low balance alerts.
+func WithBalanceAudience(audience string) BalanceOption {
+	return func(w *BalanceWatcher) {
+		w.audience = audience
+	}
+}
+
+// WithBalanceChannel sets the channel for low balance alerts. The default is Email,
+// which keeps working for a while after SMS has become unaffordable.
+func WithBalanceChannel(channel Channel) BalanceOption {
+	return func(w *BalanceWatcher) {
+		w.channel = channel
+	}
+}
+
+// WithBalanceCheckInterval sets how often the balance is checked.
+func WithBalanceCheckInterval(interval time.Duration) BalanceOption {
+	return func(w *BalanceWatcher) {
+		w.interval = interval
+	}
+}
+
+// WithBalanceSender sends low balance alerts through sender instead of the watched client.
+func WithBalanceSender(sender AlertSender) BalanceOption {
+	return func(w *BalanceWatcher) {
+		w.sender = sender
+	}
+}
+
+// WithBalanceErrorHandler sets a function called when checking the balance or
+// sending an alert fails.
+func WithBalanceErrorHandler(fn func(err error)) BalanceOption {
+	return func(w *BalanceWatcher) {
+		w.onError = fn
+	}
+}
+
+// BalanceWatcher periodically checks the account balance and sends an alert when
+// it drops below a threshold, before sends start failing with
+// NotifoxInsufficientBalanceError. It alerts once per drop and re-arms when the
+// balance is topped up above the threshold.
+type BalanceWatcher struct {
+	client    *Client
+	sender    AlertSender
+	threshold float64
+	audience  string
+	channel   Channel
+	interval  time.Duration
+	onError   func(err error)
+
+	// alerted is set once a low balance alert has been sent.
+	alerted bool
+}
+
+// NewBalanceWatcher creates a watcher that alerts when client's balance drops below threshold.
+func NewBalanceWatcher(client *Client, threshold float64, opts ...BalanceOption) *BalanceWatcher {
+	w := &BalanceWatcher{
+		client:    client,
+		sender:    client,
+		threshold: threshold,
+		channel:   Email,
+		interval:  DefaultBalanceCheckInterval,
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w
+}
+
+// Run checks the balance immediately and then every interval until ctx is done.
+func (w *BalanceWatcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// check fetches the balance once and alerts if it has dropped below the threshold.
+func (w *BalanceWatcher) check(ctx context.Context) {
+	balance, err := w.client.GetBalance(ctx)
+	if err != nil {
+		if w.onError != nil && ctx.Err() == nil {
+			w.onError(err)
+		}
+		return
+	}
+
+	if balance.Amount >= w.threshold {
+		w.alerted = false
+		return
+	}
+	if w.alerted {
+		return
+	}
+
+	msg := fmt.Sprintf("Notifox balance is low: %.2f %s remaining (threshold %.2f %s). Top up before alerts stop sending.",
+		balance.Amount, balance.Currency, w.threshold, balance.Currency)
+	if _, err := w.sender.SendAlert(ctx, AlertRequest{Audience: w.audience, Channel: w.channel, Alert: msg}); err != nil {
+		if w.onError != nil {
+			w.onError(err)
+		}
+		return
+	}
+	w.alerted = true
+}
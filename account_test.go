@@ -0,0 +1,91 @@
+package notifox
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetUsage(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/account/usage" {
+			t.Errorf("expected /account/usage, got %s", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("from"); got != "2024-01-01T00:00:00Z" {
+			t.Errorf("from = %q, want 2024-01-01T00:00:00Z", got)
+		}
+		if got := r.URL.Query().Get("to"); got != "2024-02-01T00:00:00Z" {
+			t.Errorf("to = %q, want 2024-02-01T00:00:00Z", got)
+		}
+
+		json.NewEncoder(w).Encode(Usage{
+			From: from, To: to, Messages: 3, Parts: 4, Cost: 0.1, Currency: "USD",
+			ByAudience: []UsageBreakdown{{Audience: "oncall", Messages: 3, Parts: 4, Cost: 0.1, Currency: "USD"}},
+			ByChannel: []UsageBreakdown{
+				{Channel: SMS, Messages: 2, Parts: 3, Cost: 0.075, Currency: "USD"},
+				{Channel: Email, Messages: 1, Parts: 1, Cost: 0.025, Currency: "USD"},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	usage, err := client.GetUsage(context.Background(), from, to)
+	if err != nil {
+		t.Fatalf("GetUsage() unexpected error: %v", err)
+	}
+	if usage.Messages != 3 || len(usage.ByAudience) != 1 || len(usage.ByChannel) != 2 {
+		t.Errorf("unexpected usage %+v", usage)
+	}
+	if usage.ByChannel[0].Channel != SMS || usage.ByChannel[0].Cost != 0.075 {
+		t.Errorf("unexpected channel breakdown %+v", usage.ByChannel[0])
+	}
+
+	if _, err := client.GetUsage(context.Background(), to, from); err == nil {
+		t.Error("GetUsage() expected error for reversed range, got nil")
+	}
+}
+
+func TestBalanceWatcher(t *testing.T) {
+	balance := 20.0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Balance{Amount: balance, Currency: "USD"})
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	sender := &recordingSender{}
+	w := NewBalanceWatcher(client, 10, WithBalanceAudience("billing"), WithBalanceSender(sender))
+	ctx := context.Background()
+
+	for _, b := range []float64{20, 5, 4, 50, 3} {
+		balance = b
+		w.check(ctx)
+	}
+
+	reqs := sender.requests()
+	if len(reqs) != 2 {
+		t.Fatalf("expected 2 low balance alerts (one per drop), got %d", len(reqs))
+	}
+	if reqs[0].Channel != Email || reqs[0].Audience != "billing" {
+		t.Errorf("alert sent to %q/%q, want billing/email", reqs[0].Audience, reqs[0].Channel)
+	}
+	if !strings.Contains(reqs[0].Alert, "5.00 USD remaining") {
+		t.Errorf("unexpected alert %q", reqs[0].Alert)
+	}
+}
@@ -0,0 +1,236 @@
+package notifox
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ContactMethodType is the kind of a contact method.
+type ContactMethodType string
+
+const (
+	// ContactPhone is a phone number, reached over SMS.
+	ContactPhone ContactMethodType = "phone"
+	// ContactEmail is an email address.
+	ContactEmail ContactMethodType = "email"
+)
+
+// Channel returns the delivery channel that reaches this type of contact method.
+func (t ContactMethodType) Channel() Channel {
+	switch t {
+	case ContactPhone:
+		return SMS
+	case ContactEmail:
+		return Email
+	default:
+		return ""
+	}
+}
+
+// ContactMethod is a phone number or email address belonging to an audience.
+type ContactMethod struct {
+	ID         string            `json:"id,omitempty"`
+	Type       ContactMethodType `json:"type"`
+	Value      string            `json:"value"`
+	Verified   bool              `json:"verified,omitempty"`
+	VerifiedAt *time.Time        `json:"verified_at,omitempty"`
+}
+
+// Audience is a named set of contact methods that alerts can be sent to.
+type Audience struct {
+	// ID is the identifier used in AlertRequest.Audience.
+	ID             string          `json:"id"`
+	Name           string          `json:"name"`
+	Verified       bool            `json:"verified"`
+	ContactMethods []ContactMethod `json:"contact_methods"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// VerifiedChannels returns the channels the audience has a verified contact method for.
+func (a *Audience) VerifiedChannels() []Channel {
+	var channels []Channel
+	seen := make(map[Channel]bool)
+	for _, m := range a.ContactMethods {
+		ch := m.Type.Channel()
+		if m.Verified && ch != "" && !seen[ch] {
+			seen[ch] = true
+			channels = append(channels, ch)
+		}
+	}
+	return channels
+}
+
+// ListAudiencesOptions configures a ListAudiences call.
+type ListAudiencesOptions struct {
+	// Limit is the maximum number of audiences to return. Zero uses the server default.
+	Limit int
+	// Cursor is the NextCursor from a previous page.
+	Cursor string
+}
+
+// AudienceList is a page of audiences.
+type AudienceList struct {
+	Audiences []Audience `json:"audiences"`
+	// NextCursor fetches the next page; it is empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// CreateAudienceRequest represents a request to create an audience.
+type CreateAudienceRequest struct {
+	ID             string          `json:"id"`
+	Name           string          `json:"name,omitempty"`
+	ContactMethods []ContactMethod `json:"contact_methods"`
+}
+
+// Verification is a pending contact method verification.
+type Verification struct {
+	ContactMethodID string    `json:"contact_method_id"`
+	Status          string    `json:"status"`
+	ExpiresAt       time.Time `json:"expires_at"`
+}
+
+// confirmVerificationRequest represents a request to confirm a verification code.
+type confirmVerificationRequest struct {
+	Code string `json:"code"`
+}
+
+// ListAudiences returns a page of the account's audiences.
+func (c *Client) ListAudiences(ctx context.Context, opts ListAudiencesOptions) (*AudienceList, error) {
+	query := url.Values{}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Cursor != "" {
+		query.Set("cursor", opts.Cursor)
+	}
+	path := "/audiences"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var list AudienceList
+	if err := c.doWithRetry(ctx, http.MethodGet, path, nil, &list); err != nil {
+		return nil, err
+	}
+
+	return &list, nil
+}
+
+// AllAudiences iterates over every audience, fetching pages as needed. Iteration
+// stops after the first error.
+func (c *Client) AllAudiences(ctx context.Context) iter.Seq2[*Audience, error] {
+	return func(yield func(*Audience, error) bool) {
+		opts := ListAudiencesOptions{}
+		for {
+			list, err := c.ListAudiences(ctx, opts)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for i := range list.Audiences {
+				if !yield(&list.Audiences[i], nil) {
+					return
+				}
+			}
+			if list.NextCursor == "" {
+				return
+			}
+			opts.Cursor = list.NextCursor
+		}
+	}
+}
+
+// GetAudience returns a single audience.
+func (c *Client) GetAudience(ctx context.Context, audienceID string) (*Audience, error) {
+	if audienceID == "" {
+		return nil, fmt.Errorf("audience cannot be empty")
+	}
+
+	var audience Audience
+	if err := c.doWithRetry(ctx, http.MethodGet, audiencePath(audienceID), nil, &audience); err != nil {
+		return nil, err
+	}
+
+	return &audience, nil
+}
+
+// CreateAudience creates an audience. Its contact methods must be verified with
+// StartVerification and ConfirmVerification before alerts reach them.
+func (c *Client) CreateAudience(ctx context.Context, req CreateAudienceRequest) (*Audience, error) {
+	if req.ID == "" {
+		return nil, fmt.Errorf("audience cannot be empty")
+	}
+	if len(req.ContactMethods) == 0 {
+		return nil, fmt.Errorf("at least one contact method is required")
+	}
+	for _, m := range req.ContactMethods {
+		if m.Type != ContactPhone && m.Type != ContactEmail {
+			return nil, fmt.Errorf("contact method type must be either 'phone' or 'email'")
+		}
+		if m.Value == "" {
+			return nil, fmt.Errorf("contact method value cannot be empty")
+		}
+	}
+
+	var audience Audience
+	if err := c.doRequest(ctx, http.MethodPost, "/audiences", req, &audience); err != nil {
+		return nil, err
+	}
+
+	return &audience, nil
+}
+
+// StartVerification sends a verification code to one of an audience's contact methods.
+func (c *Client) StartVerification(ctx context.Context, audienceID, contactMethodID string) (*Verification, error) {
+	if audienceID == "" || contactMethodID == "" {
+		return nil, fmt.Errorf("audience and contact method cannot be empty")
+	}
+
+	var v Verification
+	if err := c.doRequest(ctx, http.MethodPost, contactMethodPath(audienceID, contactMethodID)+"/verify", nil, &v); err != nil {
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+// ConfirmVerification confirms a contact method with the code it received and
+// returns the updated audience.
+func (c *Client) ConfirmVerification(ctx context.Context, audienceID, contactMethodID, code string) (*Audience, error) {
+	if audienceID == "" || contactMethodID == "" {
+		return nil, fmt.Errorf("audience and contact method cannot be empty")
+	}
+	if code == "" {
+		return nil, fmt.Errorf("verification code cannot be empty")
+	}
+
+	var audience Audience
+	path := contactMethodPath(audienceID, contactMethodID) + "/verify/confirm"
+	if err := c.doRequest(ctx, http.MethodPost, path, confirmVerificationRequest{Code: code}, &audience); err != nil {
+		return nil, err
+	}
+
+	return &audience, nil
+}
+
+// DeleteAudience deletes an audience.
+func (c *Client) DeleteAudience(ctx context.Context, audienceID string) error {
+	if audienceID == "" {
+		return fmt.Errorf("audience cannot be empty")
+	}
+
+	return c.doWithRetry(ctx, http.MethodDelete, audiencePath(audienceID), nil, nil)
+}
+
+func audiencePath(audienceID string) string {
+	return "/audiences/" + url.PathEscape(audienceID)
+}
+
+func contactMethodPath(audienceID, contactMethodID string) string {
+	return audiencePath(audienceID) + "/contact-methods/" + url.PathEscape(contactMethodID)
+}
@@ -0,0 +1,177 @@
+package notifox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestListAudiencesPagination(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/audiences" {
+			t.Errorf("expected /audiences, got %s", r.URL.Path)
+		}
+		if r.URL.Query().Get("limit") != "2" {
+			t.Errorf("expected limit=2, got %q", r.URL.Query().Get("limit"))
+		}
+
+		switch r.URL.Query().Get("cursor") {
+		case "":
+			json.NewEncoder(w).Encode(AudienceList{
+				Audiences:  []Audience{{ID: "oncall"}, {ID: "admins"}},
+				NextCursor: "page-2",
+			})
+		case "page-2":
+			json.NewEncoder(w).Encode(AudienceList{Audiences: []Audience{{ID: "finance"}}})
+		default:
+			t.Errorf("unexpected cursor %q", r.URL.Query().Get("cursor"))
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	list, err := client.ListAudiences(context.Background(), ListAudiencesOptions{Limit: 2})
+	if err != nil {
+		t.Fatalf("ListAudiences() unexpected error: %v", err)
+	}
+	if len(list.Audiences) != 2 || list.NextCursor != "page-2" {
+		t.Errorf("unexpected first page %+v", list)
+	}
+
+	list, err = client.ListAudiences(context.Background(), ListAudiencesOptions{Limit: 2, Cursor: list.NextCursor})
+	if err != nil {
+		t.Fatalf("ListAudiences() unexpected error: %v", err)
+	}
+	if len(list.Audiences) != 1 || list.NextCursor != "" {
+		t.Errorf("unexpected last page %+v", list)
+	}
+}
+
+func TestAudienceLifecycle(t *testing.T) {
+	audience := Audience{
+		ID:   "oncall",
+		Name: "On-call",
+		ContactMethods: []ContactMethod{
+			{ID: "cm-1", Type: ContactPhone, Value: "+15551234567"},
+			{ID: "cm-2", Type: ContactEmail, Value: "oncall@example.com", Verified: true},
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /audiences", func(w http.ResponseWriter, r *http.Request) {
+		var req CreateAudienceRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.ID != "oncall" || len(req.ContactMethods) != 2 {
+			t.Errorf("unexpected create request %+v", req)
+		}
+		json.NewEncoder(w).Encode(audience)
+	})
+	mux.HandleFunc("GET /audiences/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != "oncall" {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "audience not found", Code: CodeAudienceNotFound})
+			return
+		}
+		json.NewEncoder(w).Encode(audience)
+	})
+	mux.HandleFunc("POST /audiences/{id}/contact-methods/{cm}/verify", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Verification{ContactMethodID: r.PathValue("cm"), Status: "pending"})
+	})
+	mux.HandleFunc("POST /audiences/{id}/contact-methods/{cm}/verify/confirm", func(w http.ResponseWriter, r *http.Request) {
+		var req confirmVerificationRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Code != "123456" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "invalid code"})
+			return
+		}
+		audience.ContactMethods[0].Verified = true
+		audience.Verified = true
+		json.NewEncoder(w).Encode(audience)
+	})
+	mux.HandleFunc("DELETE /audiences/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+	ctx := context.Background()
+
+	created, err := client.CreateAudience(ctx, CreateAudienceRequest{
+		ID:             "oncall",
+		Name:           "On-call",
+		ContactMethods: audience.ContactMethods,
+	})
+	if err != nil {
+		t.Fatalf("CreateAudience() unexpected error: %v", err)
+	}
+	if got := created.VerifiedChannels(); !reflect.DeepEqual(got, []Channel{Email}) {
+		t.Errorf("VerifiedChannels() = %v, want [email]", got)
+	}
+
+	v, err := client.StartVerification(ctx, "oncall", "cm-1")
+	if err != nil {
+		t.Fatalf("StartVerification() unexpected error: %v", err)
+	}
+	if v.ContactMethodID != "cm-1" || v.Status != "pending" {
+		t.Errorf("unexpected verification %+v", v)
+	}
+
+	verified, err := client.ConfirmVerification(ctx, "oncall", "cm-1", "123456")
+	if err != nil {
+		t.Fatalf("ConfirmVerification() unexpected error: %v", err)
+	}
+	if got := verified.VerifiedChannels(); !reflect.DeepEqual(got, []Channel{SMS, Email}) {
+		t.Errorf("VerifiedChannels() = %v, want [sms email]", got)
+	}
+
+	if _, err := client.GetAudience(ctx, "oncall"); err != nil {
+		t.Errorf("GetAudience() unexpected error: %v", err)
+	}
+	if _, err := client.GetAudience(ctx, "typo"); !errors.Is(err, ErrInvalidAudience) {
+		t.Errorf("GetAudience() expected ErrInvalidAudience, got %v", err)
+	}
+
+	if err := client.DeleteAudience(ctx, "oncall"); err != nil {
+		t.Errorf("DeleteAudience() unexpected error: %v", err)
+	}
+}
+
+func TestAllAudiences(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("cursor") == "" {
+			json.NewEncoder(w).Encode(AudienceList{Audiences: []Audience{{ID: "a"}, {ID: "b"}}, NextCursor: "next"})
+			return
+		}
+		json.NewEncoder(w).Encode(AudienceList{Audiences: []Audience{{ID: "c"}}})
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	var ids []string
+	for audience, err := range client.AllAudiences(context.Background()) {
+		if err != nil {
+			t.Fatalf("AllAudiences() unexpected error: %v", err)
+		}
+		ids = append(ids, audience.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"a", "b", "c"}) {
+		t.Errorf("AllAudiences() = %v, want [a b c]", ids)
+	}
+}
@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 )
 
@@ -28,25 +31,70 @@ const (
 
 // Client is the Notifox API client.
 type Client struct {
-	apiKey     string
-	baseURL    string
-	timeout    time.Duration
-	maxRetries int
-	httpClient *http.Client
-	UserAgent  string
+	apiKey      string
+	credentials CredentialProvider
+	baseURL     string
+	timeout     time.Duration
+	maxRetries  int
+	// attemptTimeout and totalTimeout bound a single attempt and a whole request
+	// including retries. Zero means no limit beyond the context.
+	attemptTimeout time.Duration
+	totalTimeout   time.Duration
+	httpClient     *http.Client
+	UserAgent      string
+
+	transportOpts []transportOption
+
+	// apiKeyErr explains a missing key, e.g. a profile's unset api_key_env. It
+	// is only returned if no other key is configured.
+	apiKeyErr error
+
+	hedgeDelay  time.Duration
+	hedgeOnce   sync.Once
+	hedgeClient *http.Client
+
+	tracer       Tracer
+	logger       *slog.Logger
+	redactFields map[string]bool
+
+	contentFilter *ContentFilter
+	urlShortener  URLShortener
+	linkMinLength int
+
+	endpointCfg endpointConfig
+	endpoints   *endpointSet
+	stopProbe   context.CancelFunc
+	closeOnce   sync.Once
+}
+
+// AlertSender sends alerts. It is implemented by *Client and accepted by the
+// monitors in this package, so they can be pointed at a wrapped or fake sender.
+type AlertSender interface {
+	SendAlert(ctx context.Context, req AlertRequest) (*AlertResponse, error)
+}
+
+// AlertSenderFunc adapts a function to an AlertSender, e.g. to route alerts through
+// a secondary path such as a chat webhook.
+type AlertSenderFunc func(ctx context.Context, req AlertRequest) (*AlertResponse, error)
+
+// SendAlert calls f(ctx, req).
+func (f AlertSenderFunc) SendAlert(ctx context.Context, req AlertRequest) (*AlertResponse, error) {
+	return f(ctx, req)
 }
 
 // ClientOption is a function that configures a Client.
 type ClientOption func(*Client)
 
-// WithBaseURL sets the base URL for the client.
+// WithBaseURL sets the base URL for the client. It replaces WithEndpoints.
 func WithBaseURL(url string) ClientOption {
 	return func(c *Client) {
 		c.baseURL = url
+		c.endpointCfg.endpoints = nil
 	}
 }
 
-// WithTimeout sets the timeout for API requests.
+// WithTimeout sets the timeout for each attempt of an API request, including
+// reading the response. See WithAttemptTimeout and WithTotalTimeout.
 func WithTimeout(timeout time.Duration) ClientOption {
 	return func(c *Client) {
 		c.timeout = timeout
@@ -54,6 +102,23 @@ func WithTimeout(timeout time.Duration) ClientOption {
 	}
 }
 
+// WithAttemptTimeout limits each attempt of a request, so a slow response is
+// abandoned and retried. Unlike WithTimeout it is enforced through the request
+// context, and the resulting error has Reason ReasonAttemptTimeout.
+func WithAttemptTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.attemptTimeout = timeout
+	}
+}
+
+// WithTotalTimeout limits a whole request, including retries and the backoff
+// between them. Without it, a request can take up to (retries+1) attempt timeouts.
+func WithTotalTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.totalTimeout = timeout
+	}
+}
+
 // WithMaxRetries sets the maximum number of retries for failed requests.
 func WithMaxRetries(maxRetries int) ClientOption {
 	return func(c *Client) {
@@ -70,9 +135,11 @@ func WithHTTPClient(httpClient *http.Client) ClientOption {
 
 // WithAPIKey sets the API key for the client. Optional when using NewClientWithOptions;
 // if not set, the key is read from the NOTIFOX_API_KEY environment variable.
+// It replaces WithCredentialProvider; whichever option comes last wins.
 func WithAPIKey(apiKey string) ClientOption {
 	return func(c *Client) {
 		c.apiKey = apiKey
+		c.credentials = nil
 	}
 }
 
@@ -101,10 +168,8 @@ func NewClient() (*Client, error) {
 		baseURL:    DefaultBaseURL,
 		timeout:    DefaultTimeout,
 		maxRetries: DefaultMaxRetries,
-		httpClient: &http.Client{
-			Timeout: DefaultTimeout,
-		},
-		UserAgent: DefaultUserAgent,
+		httpClient: newHTTPClient(),
+		UserAgent:  DefaultUserAgent,
 	}, nil
 }
 
@@ -115,23 +180,32 @@ func NewClientWithOptions(opts ...ClientOption) (*Client, error) {
 		baseURL:    DefaultBaseURL,
 		timeout:    DefaultTimeout,
 		maxRetries: DefaultMaxRetries,
-		httpClient: &http.Client{
-			Timeout: DefaultTimeout,
-		},
-		UserAgent: DefaultUserAgent,
+		httpClient: newHTTPClient(),
+		UserAgent:  DefaultUserAgent,
 	}
 
 	for _, opt := range opts {
 		opt(client)
 	}
 
-	if client.apiKey == "" {
+	if err := client.applyTransportOptions(); err != nil {
+		return nil, err
+	}
+
+	if client.credentials == nil && client.apiKey == "" {
 		client.apiKey = os.Getenv(EnvAPIKey)
 	}
-	if client.apiKey == "" {
+	if client.credentials == nil && client.apiKey == "" && client.apiKeyErr != nil {
+		return nil, client.apiKeyErr
+	}
+	if client.credentials == nil && client.apiKey == "" {
 		return nil, fmt.Errorf("api key is required (set %s environment variable or use notifox.WithAPIKey)", EnvAPIKey)
 	}
 
+	if err := client.setupEndpoints(); err != nil {
+		return nil, err
+	}
+
 	return client, nil
 }
 
@@ -150,43 +224,174 @@ func (c *Client) SendAlert(ctx context.Context, req AlertRequest) (*AlertRespons
 		return nil, fmt.Errorf("channel must be either 'sms' or 'email'")
 	}
 
-	url := fmt.Sprintf("%s/alert", c.baseURL)
+	if req.Delay < 0 {
+		return nil, fmt.Errorf("delay cannot be negative")
+	}
+	if req.Delay > 0 {
+		if req.SendAt != nil {
+			return nil, fmt.Errorf("send at and delay cannot both be set")
+		}
+		sendAt := time.Now().Add(req.Delay)
+		req.SendAt = &sendAt
+	}
 
-	var err error
-	var result interface{}
+	redactions, err := c.filterAlert(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
 
-	for attempt := 0; attempt <= c.maxRetries; attempt++ {
-		result, err = c.doRequest(ctx, http.MethodPost, url, req, &AlertResponse{})
-		if err == nil {
-			return result.(*AlertResponse), nil
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = newRandomID()
+	}
+	c.shortenLinks(ctx, &req)
+
+	shortened := false
+	var transliterated []string
+	if req.Channel != Email {
+		if req.Transliterate != nil {
+			result := req.Transliterate.Transliterate(req.Alert)
+			req.Alert, transliterated = result.Text, result.Forced
 		}
+		if req.Shorten != nil {
+			result := ShortenSMS(req.Alert, *req.Shorten)
+			req.Alert, shortened = result.Text, result.Shortened
+		}
+	}
 
-		// Don't retry on authentication errors or rate limit errors
-		if _, isAuthErr := err.(*NotifoxAuthenticationError); isAuthErr {
-			return nil, err
+	path := "/alert"
+	if req.SendAt != nil {
+		path = scheduledPath
+	}
+
+	ctx = withRequestOptions(ctx, requestOptions{idempotencyKey: req.IdempotencyKey})
+
+	ctx, span := c.startSpan(ctx, "notifox.SendAlert",
+		Attr("notifox.audience", req.Audience), Attr("notifox.channel", string(req.Channel)))
+
+	var resp *AlertResponse
+	requests := 0
+	err = c.retry(ctx, func(ctx context.Context) error {
+		var n int
+		var err error
+		resp, n, err = c.sendHedged(ctx, path, req)
+		requests += n
+		return err
+	})
+	if err != nil {
+		// Count hedged attempts too, not just retries.
+		setAttempts(err, requests)
+		var e interface{ base() *NotifoxError }
+		if errors.As(err, &e) {
+			span.SetAttributes(Attr("notifox.attempts", e.base().Attempts))
+		}
+		if status := errorStatus(err); status != 0 {
+			span.SetAttributes(Attr("http.response.status_code", status))
+		}
+		endSpan(span, err)
+		if req.SendAt != nil && isUnsupported(err) {
+			return nil, fmt.Errorf("%w: %w", ErrSchedulingUnsupported, err)
 		}
-		if _, isRateLimitErr := err.(*NotifoxRateLimitError); isRateLimitErr {
-			return nil, err
+		return nil, err
+	}
+
+	resp.Attempts = requests
+	span.SetAttributes(
+		Attr("notifox.attempts", resp.Attempts),
+		Attr("notifox.message_id", resp.MessageID),
+		Attr("notifox.parts", resp.Parts),
+		Attr("notifox.cost", resp.Cost),
+		Attr("notifox.currency", resp.Currency),
+	)
+	endSpan(span, nil)
+	resp.Redactions = redactions
+	resp.Shortened = shortened
+	resp.IdempotencyKey = req.IdempotencyKey
+	if req.Channel != Email {
+		resp.UCS2Characters = UCS2Characters(req.Alert)
+		resp.Transliterated = transliterated
+	}
+	return resp, nil
+}
+
+// doWithRetry performs a request with doRequest, retrying server errors and
+// connection failures with backoff.
+func (c *Client) doWithRetry(ctx context.Context, method, path string, body interface{}, result interface{}) error {
+	return c.retry(ctx, func(ctx context.Context) error {
+		return c.doRequest(ctx, method, path, body, result)
+	})
+}
+
+// retry calls attempt until it succeeds, fails with an error that isn't retryable,
+// or the retries run out.
+func (c *Client) retry(ctx context.Context, attempt func(ctx context.Context) error) error {
+	callerCtx := ctx
+	if c.totalTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.totalTimeout)
+		defer cancel()
+	}
+
+	var err error
+
+	for n := 0; n <= c.maxRetries; n++ {
+		err = attempt(ctx)
+		if err == nil {
+			return nil
 		}
-		// Don't retry on other 4xx client errors (bad requests, etc.)
-		if apiErr, isAPIErr := err.(*NotifoxAPIError); isAPIErr && apiErr.StatusCode >= 400 && apiErr.StatusCode < 500 {
-			return nil, err
+		markTotalTimeout(callerCtx, err)
+
+		// Only retry server errors and connection failures; client errors
+		// (bad requests, auth, rate limits, balance) would fail again.
+		if !IsRetryable(err) {
+			c.logRetry(ctx, slog.LevelDebug, "notifox not retrying", n+1, 0, err)
+			setAttempts(err, n+1)
+			return err
 		}
 
 		// Don't retry on the last attempt
-		if attempt < c.maxRetries {
+		if n < c.maxRetries {
 			// Simple exponential backoff
-			backoff := time.Duration(attempt+1) * 100 * time.Millisecond
+			backoff := time.Duration(n+1) * 100 * time.Millisecond
+			c.logRetry(ctx, slog.LevelInfo, "notifox retrying", n+1, backoff, err)
 			select {
 			case <-ctx.Done():
-				return nil, ctx.Err()
+				err = &NotifoxConnectionError{
+					NotifoxError: NotifoxError{Message: "retry aborted", Attempts: n + 1},
+					Err:          fmt.Errorf("%w (last error: %v)", ctx.Err(), err),
+					Reason:       cancelReason(ctx),
+				}
+				markTotalTimeout(callerCtx, err)
+				c.logRetry(ctx, slog.LevelWarn, "notifox giving up", n+1, 0, err)
+				return err
 			case <-time.After(backoff):
 				// Continue to next attempt
 			}
 		}
 	}
 
-	return nil, err
+	setAttempts(err, c.maxRetries+1)
+	c.logRetry(ctx, slog.LevelWarn, "notifox giving up", c.maxRetries+1, 0, err)
+	return err
+}
+
+// markTotalTimeout changes a ReasonDeadline error to ReasonTotalTimeout if the
+// deadline was the client's total timeout rather than the caller's.
+func markTotalTimeout(callerCtx context.Context, err error) {
+	var connErr *NotifoxConnectionError
+	if errors.As(err, &connErr) && connErr.Reason == ReasonDeadline && callerCtx.Err() == nil {
+		connErr.Reason = ReasonTotalTimeout
+	}
+}
+
+// cancelReason returns the reason ctx is done, or "" if it isn't.
+func cancelReason(ctx context.Context) CancelReason {
+	switch ctx.Err() {
+	case context.Canceled:
+		return ReasonCanceled
+	case context.DeadlineExceeded:
+		return ReasonDeadline
+	}
+	return ""
 }
 
 // CalculateParts calculates the number of SMS parts, cost, encoding, and character count
@@ -197,24 +402,93 @@ func (c *Client) CalculateParts(ctx context.Context, alert string) (*PartsRespon
 	}
 
 	req := PartsRequest{Alert: alert}
-	url := fmt.Sprintf("%s/alert/parts", c.baseURL)
 
 	var resp PartsResponse
-	_, err := c.doRequest(ctx, http.MethodPost, url, req, &resp)
-	if err != nil {
+	if err := c.doRequest(ctx, http.MethodPost, "/alert/parts", req, &resp); err != nil {
 		return nil, err
 	}
 
 	return &resp, nil
 }
 
-// doRequest performs an HTTP request and handles the response.
-func (c *Client) doRequest(ctx context.Context, method, url string, body interface{}, result interface{}) (interface{}, error) {
+// doRequest performs an HTTP request to path (relative to the base URL) and
+// decodes a successful JSON response into result. If the request is rejected as
+// unauthorized and the credential provider has a new key, it is retried once.
+func (c *Client) doRequest(ctx context.Context, method, path string, body interface{}, result interface{}) error {
+	// Only add Authorization header for endpoints that require it
+	if path == "/alert/parts" {
+		return c.send(ctx, method, path, "", body, result)
+	}
+
+	apiKey, err := c.currentAPIKey(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	err = c.send(ctx, method, path, apiKey, body, result)
+	var authErr *NotifoxAuthenticationError
+	if errors.As(err, &authErr) && c.refreshAPIKey(ctx, apiKey) {
+		// The key was rotated since it was read; retry once with the new one.
+		if apiKey, keyErr := c.currentAPIKey(ctx); keyErr == nil {
+			return c.send(ctx, method, path, apiKey, body, result)
+		}
+	}
+	return err
+}
+
+// send performs a single HTTP request, authorized with apiKey unless it is empty.
+func (c *Client) send(ctx context.Context, method, path, apiKey string, body interface{}, result interface{}) error {
+	attemptCtx := ctx
+	if c.attemptTimeout > 0 {
+		var cancel context.CancelFunc
+		attemptCtx, cancel = context.WithTimeout(ctx, c.attemptTimeout)
+		defer cancel()
+	}
+
+	baseURL, endpoint := c.baseURLFor()
+	url := baseURL + path
+
+	attemptCtx, span := c.startSpan(attemptCtx, "notifox.http",
+		Attr("http.request.method", method), Attr("url.full", url))
+	if requestOptionsFrom(attemptCtx).freshConn {
+		span.SetAttributes(Attr("notifox.hedged", true))
+	}
+
+	start := time.Now()
+	status, err := c.sendAttempt(attemptCtx, method, url, apiKey, span.TraceParent(), body, result)
+	if status != 0 {
+		span.SetAttributes(Attr("http.response.status_code", status))
+	}
+
+	var connErr *NotifoxConnectionError
+	if errors.As(err, &connErr) && connErr.Reason == "" {
+		connErr.Reason = cancelReason(ctx)
+		if connErr.Reason == "" && (attemptCtx.Err() != nil || isTimeout(connErr.Err)) {
+			connErr.Reason = ReasonAttemptTimeout
+		}
+	}
+	c.reportEndpoint(endpoint, err)
+	c.logAttempt(ctx, method, url, status, time.Since(start), err)
+	endSpan(span, err)
+	return err
+}
+
+// isTimeout reports whether err is a network timeout, such as http.Client.Timeout expiring.
+func isTimeout(err error) bool {
+	var t interface{ Timeout() bool }
+	return errors.As(err, &t) && t.Timeout()
+}
+
+// sendAttempt performs the HTTP request for send and returns the response status,
+// or 0 if there was no response.
+func (c *Client) sendAttempt(ctx context.Context, method, url, apiKey, traceParent string, body interface{}, result interface{}) (int, error) {
 	var reqBody io.Reader
+	var jsonData []byte
 	if body != nil {
-		jsonData, err := json.Marshal(body)
+		var err error
+		jsonData, err = json.Marshal(body)
 		if err != nil {
-			return nil, &NotifoxConnectionError{
+			return 0, &NotifoxConnectionError{
 				NotifoxError: NotifoxError{Message: "failed to marshal request"},
 				Err:          err,
 			}
@@ -224,7 +498,7 @@ func (c *Client) doRequest(ctx context.Context, method, url string, body interfa
 
 	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
 	if err != nil {
-		return nil, &NotifoxConnectionError{
+		return 0, &NotifoxConnectionError{
 			NotifoxError: NotifoxError{Message: "failed to create request"},
 			Err:          err,
 		}
@@ -233,14 +507,30 @@ func (c *Client) doRequest(ctx context.Context, method, url string, body interfa
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", c.UserAgent)
 
-	// Only add Authorization header for endpoints that require it
-	if url != fmt.Sprintf("%s/alert/parts", c.baseURL) {
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+	opts := requestOptionsFrom(ctx)
+	if opts.idempotencyKey != "" {
+		req.Header.Set(IdempotencyKeyHeader, opts.idempotencyKey)
+	}
+
+	if traceParent != "" {
+		req.Header.Set(TraceParentHeader, traceParent)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	if apiKey != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
+	}
+
+	httpClient := c.httpClient
+	if opts.freshConn {
+		httpClient = c.freshHTTPClient(ctx)
+	}
+
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		return nil, &NotifoxConnectionError{
+		if c.debugEnabled(ctx) {
+			c.logBodies(ctx, req, jsonData, nil, nil)
+		}
+		return 0, &NotifoxConnectionError{
 			NotifoxError: NotifoxError{Message: "request failed"},
 			Err:          err,
 		}
@@ -249,36 +539,54 @@ func (c *Client) doRequest(ctx context.Context, method, url string, body interfa
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, &NotifoxConnectionError{
+		return resp.StatusCode, &NotifoxConnectionError{
 			NotifoxError: NotifoxError{Message: "failed to read response"},
 			Err:          err,
 		}
 	}
 
+	if c.debugEnabled(ctx) {
+		c.logBodies(ctx, req, jsonData, resp, respBody)
+	}
+
 	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-		if result != nil {
+		if result != nil && len(respBody) > 0 {
 			if err := json.Unmarshal(respBody, result); err != nil {
-				return nil, &NotifoxAPIError{
-					NotifoxError: NotifoxError{Message: "failed to unmarshal response"},
+				return resp.StatusCode, &NotifoxAPIError{
+					NotifoxError: NotifoxError{
+						Message:   "failed to unmarshal response",
+						RequestID: resp.Header.Get(RequestIDHeader),
+						Header:    resp.Header,
+					},
 					StatusCode:   resp.StatusCode,
 					ResponseText: string(respBody),
 				}
 			}
 		}
-		return result, nil
+		return resp.StatusCode, nil
 	}
 
-	// Handle 401 Unauthorized - returns plain text, not JSON
-	if resp.StatusCode == http.StatusUnauthorized {
-		return nil, parseError(resp.StatusCode, string(respBody))
-	}
+	return resp.StatusCode, parseError(resp.StatusCode, resp.Header, respBody)
+}
 
-	// Try to parse error response as JSON
-	var errorResp ErrorResponse
-	if err := json.Unmarshal(respBody, &errorResp); err == nil && errorResp.Error != "" {
-		return nil, parseError(resp.StatusCode, errorResp.Error)
-	}
+// IdempotencyKeyHeader is the request header carrying AlertRequest.IdempotencyKey.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// requestOptions are per-call settings passed in the context from a public
+// method down to the HTTP request.
+type requestOptions struct {
+	idempotencyKey string
+	// freshConn sends the request on a new connection rather than a pooled one.
+	freshConn bool
+}
+
+type requestOptionsKey struct{}
+
+func withRequestOptions(ctx context.Context, opts requestOptions) context.Context {
+	return context.WithValue(ctx, requestOptionsKey{}, opts)
+}
 
-	// Fallback to raw response body if JSON parsing fails
-	return nil, parseError(resp.StatusCode, string(respBody))
+func requestOptionsFrom(ctx context.Context) requestOptions {
+	opts, _ := ctx.Value(requestOptionsKey{}).(requestOptions)
+	return opts
 }
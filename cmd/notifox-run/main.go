@@ -0,0 +1,85 @@
+// Command notifox-run runs a command and sends a Notifox alert if it fails,
+// times out or cannot be started. With -heartbeat-url it also pings a heartbeat
+// monitor after every run, so that a run that never happens is alerted on too.
+//
+// Usage:
+//
+//	notifox-run -audience oncall-team [flags] -- command [args...]
+//
+// The API key is read from the NOTIFOX_API_KEY environment variable. The exit
+// status is the command's own, 124 if it timed out, or 127 if it could not be run.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/notifoxhq/notifox-go"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	var job notifox.Job
+	var channel string
+
+	flag.StringVar(&job.Name, "name", "", "job name used in alerts (default: the command name)")
+	flag.StringVar(&job.Audience, "audience", "", "audience to alert (required)")
+	flag.StringVar(&channel, "channel", "", "delivery channel: sms or email")
+	flag.DurationVar(&job.Timeout, "timeout", 0, "kill the command after this long (e.g. 30m)")
+	flag.BoolVar(&job.NotifySuccess, "notify-success", false, "also alert when the command succeeds")
+	flag.IntVar(&job.MaxParts, "max-parts", notifox.DefaultJobMaxParts, "SMS part budget for the alert; -1 for no limit")
+	flag.IntVar(&job.TailLines, "tail-lines", notifox.DefaultJobTailLines, "number of trailing output lines to include")
+	flag.StringVar(&job.HeartbeatURL, "heartbeat-url", "", "URL to GET after every run, e.g. a heartbeat monitor, to catch missing runs")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s -audience AUDIENCE [flags] -- command [args...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 || job.Audience == "" {
+		flag.Usage()
+		return 2
+	}
+	job.Command = flag.Arg(0)
+	job.Args = flag.Args()[1:]
+	job.Channel = notifox.Channel(channel)
+	job.Stdout = os.Stdout
+	job.Stderr = os.Stderr
+
+	client, err := notifox.NewClient()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "notifox-run: %v\n", err)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	result, err := notifox.RunJob(ctx, client, job)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "notifox-run: %v\n", err)
+	}
+	if result == nil {
+		return 2
+	}
+
+	switch {
+	case result.TimedOut:
+		return 124
+	case result.Err != nil:
+		fmt.Fprintf(os.Stderr, "notifox-run: %v\n", result.Err)
+		return 127
+	case result.ExitCode < 0:
+		// Killed by a signal.
+		return 1
+	default:
+		return result.ExitCode
+	}
+}
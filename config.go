@@ -0,0 +1,503 @@
+package notifox
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment variables read by NewClientFromConfig.
+const (
+	// EnvConfig overrides the config file path.
+	EnvConfig = "NOTIFOX_CONFIG"
+	// EnvProfile selects the config profile.
+	EnvProfile = "NOTIFOX_PROFILE"
+	// EnvBaseURL overrides the base URL.
+	EnvBaseURL = "NOTIFOX_BASE_URL"
+	// EnvTimeout overrides the request timeout (a Go duration such as "10s", or seconds).
+	EnvTimeout = "NOTIFOX_TIMEOUT"
+	// EnvMaxRetries overrides the maximum number of retries.
+	EnvMaxRetries = "NOTIFOX_MAX_RETRIES"
+	// EnvUserAgent overrides the User-Agent header.
+	EnvUserAgent = "NOTIFOX_USER_AGENT"
+)
+
+// DefaultProfile is the profile used when neither NOTIFOX_PROFILE nor the config
+// file's default_profile names one.
+const DefaultProfile = "default"
+
+// Config is a parsed config file: a set of named profiles.
+//
+// Config files may be JSON, YAML or TOML. Only the subset needed for this file is
+// supported: nested maps of scalar values, comments and quoted strings.
+//
+//	# ~/.config/notifox/config
+//	default_profile: prod
+//	profiles:
+//	  prod:
+//	    api_key_env: NOTIFOX_PROD_KEY
+//	    timeout: 10s
+//	  staging:
+//	    base_url: https://staging.api.notifox.com
+//	    api_key_file: /run/secrets/notifox-staging
+type Config struct {
+	DefaultProfile string
+	Profiles       map[string]Profile
+}
+
+// Profile is a named set of client settings. Zero fields keep the client defaults.
+type Profile struct {
+	BaseURL    string
+	Timeout    time.Duration
+	MaxRetries *int
+	UserAgent  string
+	// APIKey is the key itself. Prefer APIKeyEnv or APIKeyFile to keep secrets out of the file.
+	APIKey string
+	// APIKeyEnv names an environment variable holding the key.
+	APIKeyEnv string
+	// APIKeyFile is a file holding the key. It is re-read when it changes.
+	APIKeyFile string
+}
+
+// DefaultConfigPath returns the config file path: NOTIFOX_CONFIG if set, otherwise
+// $XDG_CONFIG_HOME/notifox/config (~/.config/notifox/config if unset).
+func DefaultConfigPath() string {
+	if path := os.Getenv(EnvConfig); path != "" {
+		return path
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "notifox", "config")
+}
+
+// NewClientFromConfig creates a client from the config file at DefaultConfigPath,
+// environment variables and opts. Settings are applied in increasing precedence:
+//
+//  1. client defaults
+//  2. the selected profile (NOTIFOX_PROFILE, else the file's default_profile, else "default")
+//  3. environment variables: NOTIFOX_API_KEY, NOTIFOX_BASE_URL, NOTIFOX_TIMEOUT,
+//     NOTIFOX_MAX_RETRIES, NOTIFOX_USER_AGENT
+//  4. opts
+//
+// A missing config file is not an error unless NOTIFOX_CONFIG points to it.
+func NewClientFromConfig(opts ...ClientOption) (*Client, error) {
+	path := DefaultConfigPath()
+
+	cfg, err := LoadConfig(path)
+	if errors.Is(err, os.ErrNotExist) && os.Getenv(EnvConfig) == "" {
+		cfg, err = &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	profileOpts, err := cfg.ClientOptions(os.Getenv(EnvProfile))
+	if err != nil {
+		return nil, err
+	}
+	envOpts, err := envClientOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	all := append(append(profileOpts, envOpts...), opts...)
+	return NewClientWithOptions(all...)
+}
+
+// ClientOptions returns the options for the named profile. An empty name selects
+// the config's default profile. If the profile's api_key_env is not set or its
+// api_key_file can't be read, creating a client fails unless a key is set by a
+// later option.
+func (c *Config) ClientOptions(name string) ([]ClientOption, error) {
+	if name == "" {
+		name = c.DefaultProfile
+	}
+	if name == "" {
+		name = DefaultProfile
+	}
+
+	p, ok := c.Profiles[name]
+	if !ok {
+		if name == DefaultProfile {
+			// No profiles configured: use the defaults.
+			return nil, nil
+		}
+		return nil, fmt.Errorf("config profile %q not found", name)
+	}
+
+	var opts []ClientOption
+	if p.BaseURL != "" {
+		opts = append(opts, WithBaseURL(p.BaseURL))
+	}
+	if p.Timeout > 0 {
+		opts = append(opts, WithTimeout(p.Timeout))
+	}
+	if p.MaxRetries != nil {
+		opts = append(opts, WithMaxRetries(*p.MaxRetries))
+	}
+	if p.UserAgent != "" {
+		opts = append(opts, WithUserAgent(p.UserAgent))
+	}
+
+	switch {
+	case p.APIKey != "":
+		opts = append(opts, WithAPIKey(p.APIKey))
+	case p.APIKeyEnv != "":
+		if key := os.Getenv(p.APIKeyEnv); key != "" {
+			opts = append(opts, WithAPIKey(key))
+		} else {
+			// Only an error if no key is set with higher precedence, e.g. NOTIFOX_API_KEY.
+			opts = append(opts, withAPIKeyError(fmt.Errorf("config profile %q: environment variable %s is not set", name, p.APIKeyEnv)))
+		}
+	case p.APIKeyFile != "":
+		// Read the file now to fail fast; the provider re-reads it when it is rotated.
+		creds := FileCredentials(p.APIKeyFile)
+		if _, err := creds.APIKey(context.Background()); err != nil {
+			// Like an unset api_key_env, only an error if no other key is set.
+			opts = append(opts, withAPIKeyError(fmt.Errorf("config profile %q: %w", name, err)))
+		} else {
+			opts = append(opts, WithCredentialProvider(creds))
+		}
+	}
+
+	return opts, nil
+}
+
+// withAPIKeyError sets the error NewClientWithOptions returns if no API key is
+// configured.
+func withAPIKeyError(err error) ClientOption {
+	return func(c *Client) {
+		c.apiKeyErr = err
+	}
+}
+
+// envClientOptions returns options for the NOTIFOX_* environment variables that are set.
+func envClientOptions() ([]ClientOption, error) {
+	var opts []ClientOption
+	if v := os.Getenv(EnvAPIKey); v != "" {
+		opts = append(opts, WithAPIKey(v))
+	}
+	if v := os.Getenv(EnvBaseURL); v != "" {
+		opts = append(opts, WithBaseURL(v))
+	}
+	if v := os.Getenv(EnvTimeout); v != "" {
+		d, err := parseConfigDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", EnvTimeout, err)
+		}
+		opts = append(opts, WithTimeout(d))
+	}
+	if v := os.Getenv(EnvMaxRetries); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid %s: %q", EnvMaxRetries, v)
+		}
+		opts = append(opts, WithMaxRetries(n))
+	}
+	if v := os.Getenv(EnvUserAgent); v != "" {
+		opts = append(opts, WithUserAgent(v))
+	}
+	return opts, nil
+}
+
+// LoadConfig reads a config file. The format is taken from the extension (.json,
+// .yaml, .yml, .toml) or, for files without one, detected from the content.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var tree map[string]any
+	switch format := configFormat(path, data); format {
+	case "json":
+		err = json.Unmarshal(data, &tree)
+	case "toml":
+		tree, err = parseTOML(data)
+	default:
+		tree, err = parseYAML(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	cfg, err := configFromTree(tree)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// configFormat returns "json", "yaml" or "toml" for a config file.
+func configFormat(path string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".toml":
+		return "toml"
+	}
+
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		return "json"
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(trimmed))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") || strings.Contains(line, "=") && !strings.Contains(line, ":") {
+			return "toml"
+		}
+		break
+	}
+	return "yaml"
+}
+
+// configFromTree converts a parsed config file into a Config.
+func configFromTree(tree map[string]any) (*Config, error) {
+	cfg := &Config{Profiles: make(map[string]Profile)}
+
+	for key, v := range tree {
+		if v == nil {
+			// A null value is the same as leaving the key out.
+			continue
+		}
+		switch key {
+		case "default_profile":
+			cfg.DefaultProfile = fmt.Sprint(v)
+		case "profiles":
+			profiles, ok := v.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("profiles must be a map")
+			}
+			for name, pv := range profiles {
+				fields, ok := pv.(map[string]any)
+				if !ok {
+					return nil, fmt.Errorf("profile %q must be a map", name)
+				}
+				p, err := profileFromFields(fields)
+				if err != nil {
+					return nil, fmt.Errorf("profile %q: %w", name, err)
+				}
+				cfg.Profiles[name] = p
+			}
+		default:
+			return nil, fmt.Errorf("unknown key %q", key)
+		}
+	}
+
+	return cfg, nil
+}
+
+func profileFromFields(fields map[string]any) (Profile, error) {
+	var p Profile
+
+	// Sort keys so errors are deterministic.
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if fields[key] == nil {
+			continue
+		}
+		value := fmt.Sprint(fields[key])
+		switch key {
+		case "base_url":
+			p.BaseURL = value
+		case "timeout":
+			d, err := parseConfigDuration(value)
+			if err != nil {
+				return p, fmt.Errorf("invalid timeout: %w", err)
+			}
+			p.Timeout = d
+		case "max_retries":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return p, fmt.Errorf("invalid max_retries %q", value)
+			}
+			p.MaxRetries = &n
+		case "user_agent":
+			p.UserAgent = value
+		case "api_key":
+			p.APIKey = value
+		case "api_key_env":
+			p.APIKeyEnv = value
+		case "api_key_file":
+			p.APIKeyFile = value
+		default:
+			return p, fmt.Errorf("unknown key %q", key)
+		}
+	}
+
+	return p, nil
+}
+
+// parseConfigDuration parses a Go duration, or a plain number of seconds.
+func parseConfigDuration(s string) (time.Duration, error) {
+	if secs, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(secs * float64(time.Second)), nil
+	}
+	return time.ParseDuration(s)
+}
+
+// parseYAML parses the subset of YAML used by config files: nested block maps of
+// scalars, indented with spaces.
+func parseYAML(data []byte) (map[string]any, error) {
+	root := make(map[string]any)
+	type level struct {
+		indent int
+		m      map[string]any
+	}
+	stack := []level{{indent: -1, m: root}}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		raw := scanner.Text()
+		line := strings.TrimSpace(stripComment(raw, true))
+		if line == "" || line == "---" {
+			continue
+		}
+		indent := len(raw) - len(strings.TrimLeft(raw, " "))
+		if strings.HasPrefix(strings.TrimLeft(raw, " "), "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", n)
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key: value\"", n)
+		}
+		key = unquote(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		for len(stack) > 1 && indent <= stack[len(stack)-1].indent {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1].m
+
+		if value == "" {
+			child := make(map[string]any)
+			parent[key] = child
+			stack = append(stack, level{indent: indent, m: child})
+			continue
+		}
+		if isYAMLNull(value) {
+			parent[key] = nil
+			continue
+		}
+		parent[key] = unquote(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+// parseTOML parses the subset of TOML used by config files: dotted [table]
+// headers and key = value pairs of scalars.
+func parseTOML(data []byte) (map[string]any, error) {
+	root := make(map[string]any)
+	current := root
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(stripComment(scanner.Text(), false))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: malformed table header", n)
+			}
+			current = root
+			for _, part := range strings.Split(strings.Trim(line, "[]"), ".") {
+				part = unquote(strings.TrimSpace(part))
+				child, ok := current[part].(map[string]any)
+				if !ok {
+					child = make(map[string]any)
+					current[part] = child
+				}
+				current = child
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"key = value\"", n)
+		}
+		current[unquote(strings.TrimSpace(key))] = unquote(strings.TrimSpace(value))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+// stripComment removes a trailing # comment that is not inside quotes. If
+// spaced is set, as in YAML, a # only starts a comment at the start of the line
+// or after a space or tab, so values like abc#123 and URL fragments are kept.
+func stripComment(line string, spaced bool) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			if !spaced || i == 0 || line[i-1] == ' ' || line[i-1] == '\t' {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
+
+// isYAMLNull reports whether an unquoted YAML scalar is null.
+func isYAMLNull(s string) bool {
+	switch s {
+	case "~", "null", "Null", "NULL":
+		return true
+	}
+	return false
+}
+
+// unquote removes matching single or double quotes around s.
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' && s[len(s)-1] == '"' || s[0] == '\'' && s[len(s)-1] == '\'') {
+		if s[0] == '"' {
+			if u, err := strconv.Unquote(s); err == nil {
+				return u
+			}
+		}
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,220 @@
+package notifox
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigFormats(t *testing.T) {
+	files := map[string]string{
+		"config.json": `{
+  "default_profile": "prod",
+  "profiles": {
+    "prod": {"base_url": "https://prod.example.com", "timeout": "10s", "max_retries": 5}
+  }
+}`,
+		"config.yaml": `# notifox
+default_profile: prod
+profiles:
+  prod:
+    base_url: "https://prod.example.com"  # comment
+    timeout: 10s
+    max_retries: 5
+`,
+		"config.toml": `default_profile = "prod"
+
+[profiles.prod]
+base_url = "https://prod.example.com"
+timeout = "10s"
+max_retries = 5
+`,
+		// No extension: detected from the content.
+		"config": `default_profile = "prod"
+[profiles.prod]
+base_url = "https://prod.example.com"
+timeout = 10
+max_retries = 5
+`,
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("LoadConfig() unexpected error: %v", err)
+			}
+			if cfg.DefaultProfile != "prod" {
+				t.Errorf("DefaultProfile = %q, want prod", cfg.DefaultProfile)
+			}
+			p := cfg.Profiles["prod"]
+			if p.BaseURL != "https://prod.example.com" || p.Timeout != 10*time.Second || p.MaxRetries == nil || *p.MaxRetries != 5 {
+				t.Errorf("unexpected profile %+v", p)
+			}
+		})
+	}
+}
+
+func TestLoadConfigValues(t *testing.T) {
+	files := map[string]string{
+		"config.yaml": `default_profile: ~
+profiles:
+  prod:
+    api_key: abc#123 # key
+    base_url: https://x.example.com/#frag
+    user_agent: null
+`,
+		"config.json": `{"default_profile": null, "profiles": {"prod": {"api_key": "abc#123", "base_url": "https://x.example.com/#frag", "user_agent": null}}}`,
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			os.WriteFile(path, []byte(content), 0o600)
+
+			cfg, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("LoadConfig() unexpected error: %v", err)
+			}
+			if cfg.DefaultProfile != "" {
+				t.Errorf("DefaultProfile = %q, want null to be unset", cfg.DefaultProfile)
+			}
+			p := cfg.Profiles["prod"]
+			if p.APIKey != "abc#123" || p.BaseURL != "https://x.example.com/#frag" || p.UserAgent != "" {
+				t.Errorf("unexpected profile %+v", p)
+			}
+		})
+	}
+}
+
+func TestLoadConfigUnknownKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(path, []byte("profiles:\n  prod:\n    base_ulr: https://example.com\n"), 0o600)
+
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("LoadConfig() expected error for unknown key, got nil")
+	}
+}
+
+func TestNewClientFromConfig(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "staging.key")
+	os.WriteFile(keyFile, []byte("staging-key\n"), 0o600)
+
+	path := filepath.Join(dir, "config.yaml")
+	os.WriteFile(path, []byte(`default_profile: prod
+profiles:
+  prod:
+    api_key: prod-key
+    timeout: 10s
+    max_retries: 5
+  staging:
+    api_key_file: `+keyFile+`
+    base_url: https://staging.example.com
+`), 0o600)
+
+	t.Setenv(EnvConfig, path)
+	t.Setenv(EnvAPIKey, "")
+	t.Setenv(EnvProfile, "")
+	t.Setenv(EnvBaseURL, "")
+	t.Setenv(EnvTimeout, "")
+	t.Setenv(EnvMaxRetries, "")
+	t.Setenv(EnvUserAgent, "")
+
+	client, err := NewClientFromConfig()
+	if err != nil {
+		t.Fatalf("NewClientFromConfig() unexpected error: %v", err)
+	}
+	if client.apiKey != "prod-key" || client.timeout != 10*time.Second || client.maxRetries != 5 {
+		t.Errorf("unexpected client from default profile: key=%q timeout=%v retries=%d", client.apiKey, client.timeout, client.maxRetries)
+	}
+
+	// Environment overrides the profile; options override the environment.
+	t.Setenv(EnvProfile, "staging")
+	t.Setenv(EnvTimeout, "3s")
+	client, err = NewClientFromConfig(WithBaseURL("https://override.example.com"))
+	if err != nil {
+		t.Fatalf("NewClientFromConfig() unexpected error: %v", err)
+	}
+	if key, _ := client.currentAPIKey(context.Background()); key != "staging-key" {
+		t.Errorf("expected key from file, got %q", key)
+	}
+	if client.timeout != 3*time.Second {
+		t.Errorf("expected timeout from env, got %v", client.timeout)
+	}
+	if client.baseURL != "https://override.example.com" {
+		t.Errorf("expected base URL from option, got %q", client.baseURL)
+	}
+
+	t.Setenv(EnvProfile, "missing")
+	if _, err := NewClientFromConfig(); err == nil {
+		t.Error("NewClientFromConfig() expected error for unknown profile, got nil")
+	}
+}
+
+func TestNewClientFromConfigNoFile(t *testing.T) {
+	t.Setenv(EnvConfig, "")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(EnvProfile, "")
+	t.Setenv(EnvAPIKey, "env-key")
+	t.Setenv(EnvBaseURL, "https://env.example.com")
+
+	client, err := NewClientFromConfig()
+	if err != nil {
+		t.Fatalf("NewClientFromConfig() unexpected error: %v", err)
+	}
+	if client.apiKey != "env-key" || client.baseURL != "https://env.example.com" || client.maxRetries != DefaultMaxRetries {
+		t.Errorf("unexpected client without config file: %+v", client)
+	}
+
+	t.Setenv(EnvConfig, filepath.Join(t.TempDir(), "missing"))
+	if _, err := NewClientFromConfig(); err == nil {
+		t.Error("NewClientFromConfig() expected error when NOTIFOX_CONFIG file is missing, got nil")
+	}
+}
+
+func TestNewClientFromConfigUnsetKeyEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(path, []byte(`profiles:
+  default:
+    api_key_env: NOTIFOX_TEST_UNSET_KEY
+`), 0o600)
+
+	t.Setenv(EnvConfig, path)
+	t.Setenv(EnvProfile, "")
+	t.Setenv("NOTIFOX_TEST_UNSET_KEY", "")
+
+	// A key with higher precedence makes the unset variable irrelevant.
+	t.Setenv(EnvAPIKey, "env-key")
+	if client, err := NewClientFromConfig(); err != nil || client.apiKey != "env-key" {
+		t.Errorf("NewClientFromConfig() with %s = %v, want env-key", EnvAPIKey, err)
+	}
+	t.Setenv(EnvAPIKey, "")
+	if client, err := NewClientFromConfig(WithAPIKey("option-key")); err != nil || client.apiKey != "option-key" {
+		t.Errorf("NewClientFromConfig(WithAPIKey) = %v, want option-key", err)
+	}
+
+	_, err := NewClientFromConfig()
+	if err == nil || !strings.Contains(err.Error(), "NOTIFOX_TEST_UNSET_KEY is not set") {
+		t.Errorf("NewClientFromConfig() = %v, want unset variable error", err)
+	}
+
+	// The same goes for an unreadable api_key_file.
+	os.WriteFile(path, []byte("profiles:\n  default:\n    api_key_file: "+filepath.Join(t.TempDir(), "missing")+"\n"), 0o600)
+	t.Setenv(EnvAPIKey, "env-key")
+	if client, err := NewClientFromConfig(); err != nil || client.apiKey != "env-key" {
+		t.Errorf("NewClientFromConfig() with %s = %v, want env-key", EnvAPIKey, err)
+	}
+	t.Setenv(EnvAPIKey, "")
+	if _, err := NewClientFromConfig(); err == nil || !strings.Contains(err.Error(), "api key file") {
+		t.Errorf("NewClientFromConfig() = %v, want key file error", err)
+	}
+}
@@ -0,0 +1,194 @@
+package notifox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CredentialProvider supplies the API key. The client asks for the key on every
+// request, so providers can rotate it without recreating the client.
+type CredentialProvider interface {
+	APIKey(ctx context.Context) (string, error)
+}
+
+// CredentialRefresher is implemented by providers that cache the key. The client
+// calls Refresh after an authentication failure, then retries once if the key changed.
+type CredentialRefresher interface {
+	Refresh(ctx context.Context) error
+}
+
+// WithCredentialProvider sets the provider the client gets its API key from. It
+// replaces WithAPIKey; whichever option comes last wins.
+func WithCredentialProvider(p CredentialProvider) ClientOption {
+	return func(c *Client) {
+		c.credentials = p
+		c.apiKey = ""
+	}
+}
+
+// StaticCredentials returns a provider that always returns key.
+func StaticCredentials(key string) CredentialProvider {
+	return staticCredentials(key)
+}
+
+type staticCredentials string
+
+func (s staticCredentials) APIKey(ctx context.Context) (string, error) {
+	if s == "" {
+		return "", fmt.Errorf("api key is empty")
+	}
+	return string(s), nil
+}
+
+// EnvCredentials returns a provider that reads the key from the environment
+// variable name on every request.
+func EnvCredentials(name string) CredentialProvider {
+	return envCredentials(name)
+}
+
+type envCredentials string
+
+func (e envCredentials) APIKey(ctx context.Context) (string, error) {
+	key := strings.TrimSpace(os.Getenv(string(e)))
+	if key == "" {
+		return "", fmt.Errorf("environment variable %s is not set", string(e))
+	}
+	return key, nil
+}
+
+// FileCredentialProvider reads the key from a file, such as a secret mounted by an
+// orchestrator, and re-reads it when the file's modification time or size changes.
+type FileCredentialProvider struct {
+	path string
+
+	mu      sync.Mutex
+	key     string
+	modTime time.Time
+	size    int64
+}
+
+// FileCredentials returns a provider that reads the key from path. Surrounding
+// whitespace is trimmed.
+func FileCredentials(path string) *FileCredentialProvider {
+	return &FileCredentialProvider{path: path}
+}
+
+// APIKey returns the key, re-reading the file if it changed. While a rotation is in
+// progress (file missing or empty) the previous key is returned.
+func (f *FileCredentialProvider) APIKey(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		if f.key != "" {
+			return f.key, nil
+		}
+		return "", fmt.Errorf("failed to read api key file: %w", err)
+	}
+	if f.key != "" && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.key, nil
+	}
+
+	if err := f.load(info); err != nil {
+		if f.key != "" {
+			return f.key, nil
+		}
+		return "", err
+	}
+	return f.key, nil
+}
+
+// Refresh re-reads the file even if it appears unchanged.
+func (f *FileCredentialProvider) Refresh(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return fmt.Errorf("failed to read api key file: %w", err)
+	}
+	return f.load(info)
+}
+
+// load reads the file. f.mu must be held.
+func (f *FileCredentialProvider) load(info os.FileInfo) error {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return fmt.Errorf("failed to read api key file: %w", err)
+	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return fmt.Errorf("api key file %s is empty", f.path)
+	}
+
+	f.key = key
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+	return nil
+}
+
+// ChainCredentials returns a provider that tries each provider in order and
+// returns the first key found.
+func ChainCredentials(providers ...CredentialProvider) CredentialProvider {
+	return chainCredentials(providers)
+}
+
+type chainCredentials []CredentialProvider
+
+func (c chainCredentials) APIKey(ctx context.Context) (string, error) {
+	var errs []error
+	for _, p := range c {
+		key, err := p.APIKey(ctx)
+		if err == nil && key != "" {
+			return key, nil
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return "", fmt.Errorf("no credential provider returned an api key")
+	}
+	return "", fmt.Errorf("no credential provider returned an api key: %w", errors.Join(errs...))
+}
+
+// Refresh refreshes every provider in the chain that supports it.
+func (c chainCredentials) Refresh(ctx context.Context) error {
+	var errs []error
+	for _, p := range c {
+		if r, ok := p.(CredentialRefresher); ok {
+			if err := r.Refresh(ctx); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// currentAPIKey returns the key to send with the next request.
+func (c *Client) currentAPIKey(ctx context.Context) (string, error) {
+	if c.credentials == nil {
+		return c.apiKey, nil
+	}
+	return c.credentials.APIKey(ctx)
+}
+
+// refreshAPIKey refreshes the credential provider after an authentication failure
+// with used and reports whether a different key is now available.
+func (c *Client) refreshAPIKey(ctx context.Context, used string) bool {
+	if c.credentials == nil {
+		return false
+	}
+	if r, ok := c.credentials.(CredentialRefresher); ok {
+		// A failed refresh still leaves APIKey to report whatever it has.
+		_ = r.Refresh(ctx)
+	}
+	key, err := c.credentials.APIKey(ctx)
+	return err == nil && key != "" && key != used
+}
@@ -0,0 +1,132 @@
+package notifox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	os.WriteFile(path, []byte("key-1\n"), 0o600)
+
+	creds := FileCredentials(path)
+	ctx := context.Background()
+	if key, err := creds.APIKey(ctx); err != nil || key != "key-1" {
+		t.Fatalf("APIKey() = %q, %v; want key-1", key, err)
+	}
+
+	os.WriteFile(path, []byte("key-22\n"), 0o600)
+	if key, _ := creds.APIKey(ctx); key != "key-22" {
+		t.Errorf("expected rotated key, got %q", key)
+	}
+
+	// While the file is being replaced, the last key is kept.
+	os.Remove(path)
+	if key, err := creds.APIKey(ctx); err != nil || key != "key-22" {
+		t.Errorf("APIKey() = %q, %v; want previous key", key, err)
+	}
+
+	if _, err := FileCredentials(filepath.Join(t.TempDir(), "missing")).APIKey(ctx); err == nil {
+		t.Error("APIKey() expected error for missing file, got nil")
+	}
+}
+
+func TestChainCredentials(t *testing.T) {
+	t.Setenv("NOTIFOX_TEST_KEY", "")
+	chain := ChainCredentials(EnvCredentials("NOTIFOX_TEST_KEY"), StaticCredentials("fallback"))
+	ctx := context.Background()
+
+	if key, _ := chain.APIKey(ctx); key != "fallback" {
+		t.Errorf("expected fallback key, got %q", key)
+	}
+	t.Setenv("NOTIFOX_TEST_KEY", "from-env")
+	if key, _ := chain.APIKey(ctx); key != "from-env" {
+		t.Errorf("expected env key, got %q", key)
+	}
+
+	if _, err := ChainCredentials(StaticCredentials("")).APIKey(ctx); err == nil {
+		t.Error("APIKey() expected error when no provider has a key, got nil")
+	}
+}
+
+// rotatingCredentials serves a stale key until Refresh is called.
+type rotatingCredentials struct {
+	mu        sync.Mutex
+	key, next string
+	refreshes int
+}
+
+func (r *rotatingCredentials) APIKey(ctx context.Context) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.key, nil
+}
+
+func (r *rotatingCredentials) Refresh(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refreshes++
+	r.key = r.next
+	return nil
+}
+
+func TestCredentialRotationRetry(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Authorization") != "Bearer new-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte("Unauthorized"))
+			return
+		}
+		json.NewEncoder(w).Encode(AlertResponse{MessageID: "msg-1"})
+	}))
+	defer server.Close()
+
+	creds := &rotatingCredentials{key: "old-key", next: "new-key"}
+	client, err := NewClientWithOptions(WithCredentialProvider(creds), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	resp, err := client.SendAlert(context.Background(), AlertRequest{Audience: "oncall", Alert: "rotated"})
+	if err != nil {
+		t.Fatalf("SendAlert() unexpected error: %v", err)
+	}
+	if resp.MessageID != "msg-1" || requests != 2 || creds.refreshes != 1 {
+		t.Errorf("expected one retry after refresh, got %d requests, %d refreshes", requests, creds.refreshes)
+	}
+
+	// A key that is still wrong after refreshing is not retried again.
+	requests = 0
+	creds.key, creds.next = "bad-key", "bad-key"
+	_, err = client.SendAlert(context.Background(), AlertRequest{Audience: "oncall", Alert: "bad"})
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected 1 request for an unchanged key, got %d", requests)
+	}
+}
+
+func TestWithCredentialProviderNoEnvKey(t *testing.T) {
+	t.Setenv(EnvAPIKey, "")
+	path := filepath.Join(t.TempDir(), "key")
+	os.WriteFile(path, []byte("file-key"), 0o600)
+
+	client, err := NewClientWithOptions(WithCredentialProvider(FileCredentials(path)), WithTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("NewClientWithOptions() unexpected error: %v", err)
+	}
+	if key, _ := client.currentAPIKey(context.Background()); key != "file-key" {
+		t.Errorf("expected file key, got %q", key)
+	}
+}
@@ -0,0 +1,329 @@
+package notifox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultEndpointCooldown is how long a failed endpoint is avoided.
+	DefaultEndpointCooldown = 30 * time.Second
+	// DefaultHealthProbePath is the path requested by the active health probe.
+	DefaultHealthProbePath = "/"
+)
+
+// Endpoint is an API base URL, for use with WithEndpoints.
+type Endpoint struct {
+	URL string
+	// Priority orders endpoints: requests go to the healthy endpoints with the
+	// lowest priority, failing over to higher ones.
+	Priority int
+	// Weight is the share of requests among healthy endpoints of the same
+	// priority. Defaults to 1.
+	Weight int
+}
+
+// EndpointStatus is an endpoint's current health.
+type EndpointStatus struct {
+	Endpoint
+	Healthy bool
+	// Failures is the number of consecutive failed requests or probes.
+	Failures  int
+	LastError error
+}
+
+// WithEndpoints sets several base URLs to fail over between, replacing
+// WithBaseURL. An endpoint is marked unhealthy after failing requests with
+// connection errors or 5xx responses (see WithEndpointFailover).
+func WithEndpoints(endpoints ...Endpoint) ClientOption {
+	return func(c *Client) {
+		c.endpointCfg.endpoints = endpoints
+	}
+}
+
+// WithEndpointFailover sets how many consecutive failures mark an endpoint
+// unhealthy (default 1) and how long it is then avoided (default 30s).
+func WithEndpointFailover(threshold int, cooldown time.Duration) ClientOption {
+	return func(c *Client) {
+		c.endpointCfg.threshold = threshold
+		c.endpointCfg.cooldown = cooldown
+	}
+}
+
+// WithHealthProbe probes every endpoint with a GET request to path every interval,
+// so endpoints are marked healthy or unhealthy without waiting for real traffic.
+// Any response below 500 counts as healthy. Probing runs in a goroutine that
+// keeps the client alive, so Client.Close must be called when the client is no
+// longer needed.
+func WithHealthProbe(interval time.Duration, path string) ClientOption {
+	return func(c *Client) {
+		c.endpointCfg.probeInterval = interval
+		c.endpointCfg.probePath = path
+	}
+}
+
+// endpointConfig collects the endpoint options until the client is built.
+type endpointConfig struct {
+	endpoints     []Endpoint
+	threshold     int
+	cooldown      time.Duration
+	probeInterval time.Duration
+	probePath     string
+}
+
+// Endpoints returns the health of each endpoint, in priority order. It returns
+// nil unless the client was created with WithEndpoints.
+func (c *Client) Endpoints() []EndpointStatus {
+	if c.endpoints == nil {
+		return nil
+	}
+	return c.endpoints.status()
+}
+
+// Close stops the health probe and closes idle connections. It is required for
+// clients created with WithHealthProbe, whose probe otherwise runs forever. The
+// client must not be used afterwards.
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() {
+		c.stopHealthProbe()
+		c.httpClient.CloseIdleConnections()
+	})
+	return nil
+}
+
+// stopHealthProbe stops the health probe, if any, leaving the HTTP client alone,
+// e.g. for a client whose transport is shared with others.
+func (c *Client) stopHealthProbe() {
+	if c.stopProbe != nil {
+		c.stopProbe()
+	}
+}
+
+// setupEndpoints builds the endpoint set from the endpoint options and starts the
+// health probe.
+func (c *Client) setupEndpoints() error {
+	cfg := c.endpointCfg
+	if len(cfg.endpoints) == 0 {
+		return nil
+	}
+
+	set := &endpointSet{
+		threshold: max(cfg.threshold, 1),
+		cooldown:  cfg.cooldown,
+		now:       time.Now,
+	}
+	if set.cooldown <= 0 {
+		set.cooldown = DefaultEndpointCooldown
+	}
+	for _, e := range cfg.endpoints {
+		if e.URL == "" {
+			return fmt.Errorf("endpoint URL cannot be empty")
+		}
+		if e.Weight < 0 {
+			return fmt.Errorf("endpoint %s: weight cannot be negative", e.URL)
+		}
+		if e.Weight == 0 {
+			e.Weight = 1
+		}
+		set.endpoints = append(set.endpoints, &endpointState{Endpoint: e})
+	}
+	sort.SliceStable(set.endpoints, func(i, j int) bool {
+		return set.endpoints[i].Priority < set.endpoints[j].Priority
+	})
+
+	c.endpoints = set
+	// baseURL is the primary endpoint.
+	c.baseURL = set.endpoints[0].URL
+
+	if cfg.probeInterval > 0 {
+		path := cfg.probePath
+		if path == "" {
+			path = DefaultHealthProbePath
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		c.stopProbe = cancel
+		go c.probeEndpoints(ctx, cfg.probeInterval, path)
+	}
+	return nil
+}
+
+// baseURLFor returns the base URL for the next request and, with multiple
+// endpoints, the endpoint chosen.
+func (c *Client) baseURLFor() (string, *endpointState) {
+	if c.endpoints == nil {
+		return c.baseURL, nil
+	}
+	e := c.endpoints.pick()
+	return e.URL, e
+}
+
+// reportEndpoint records the outcome of a request to e for passive health tracking.
+func (c *Client) reportEndpoint(e *endpointState, err error) {
+	if e == nil {
+		return
+	}
+
+	var connErr *NotifoxConnectionError
+	var apiErr *NotifoxAPIError
+	switch {
+	case errors.As(err, &connErr):
+		// The caller giving up says nothing about the endpoint.
+		if connErr.Reason == ReasonCanceled || connErr.Reason == ReasonDeadline || connErr.Reason == ReasonTotalTimeout {
+			return
+		}
+		c.endpoints.report(e, err)
+	case errors.As(err, &apiErr) && apiErr.StatusCode >= 500:
+		c.endpoints.report(e, err)
+	default:
+		c.endpoints.report(e, nil)
+	}
+}
+
+// probeEndpoints runs the active health probe until ctx is done.
+func (c *Client) probeEndpoints(ctx context.Context, interval time.Duration, path string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for _, e := range c.endpoints.list() {
+			c.endpoints.report(e, c.probe(ctx, e.URL+path, interval))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probe requests url and returns an error if the endpoint looks unhealthy.
+func (c *Client) probe(ctx context.Context, url string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("health probe returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// endpointSet tracks the health of a client's endpoints.
+type endpointSet struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	endpoints []*endpointState // sorted by priority
+}
+
+type endpointState struct {
+	Endpoint
+	failures  int
+	lastErr   error
+	downUntil time.Time
+}
+
+// pick returns a healthy endpoint of the lowest available priority, chosen by
+// weight. If none is healthy, the one that will recover soonest is returned.
+func (s *endpointSet) pick() *endpointState {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var candidates []*endpointState
+	total := 0
+	for _, e := range s.endpoints {
+		if now.Before(e.downUntil) {
+			continue
+		}
+		if len(candidates) > 0 && e.Priority != candidates[0].Priority {
+			break
+		}
+		candidates = append(candidates, e)
+		total += e.Weight
+	}
+
+	if len(candidates) == 0 {
+		soonest := s.endpoints[0]
+		for _, e := range s.endpoints[1:] {
+			if e.downUntil.Before(soonest.downUntil) {
+				soonest = e
+			}
+		}
+		return soonest
+	}
+
+	n := rand.IntN(total)
+	for _, e := range candidates {
+		if n < e.Weight {
+			return e
+		}
+		n -= e.Weight
+	}
+	return candidates[len(candidates)-1]
+}
+
+// report records a success (err == nil) or failure of e.
+func (s *endpointSet) report(e *endpointState, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err == nil {
+		e.failures = 0
+		e.lastErr = nil
+		e.downUntil = time.Time{}
+		return
+	}
+
+	e.failures++
+	e.lastErr = err
+	if e.failures >= s.threshold {
+		e.downUntil = s.now().Add(s.cooldown)
+	}
+}
+
+func (s *endpointSet) list() []*endpointState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]*endpointState(nil), s.endpoints...)
+}
+
+func (s *endpointSet) status() []EndpointStatus {
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := make([]EndpointStatus, 0, len(s.endpoints))
+	for _, e := range s.endpoints {
+		status = append(status, EndpointStatus{
+			Endpoint:  e.Endpoint,
+			Healthy:   !now.Before(e.downUntil),
+			Failures:  e.failures,
+			LastError: e.lastErr,
+		})
+	}
+	return status
+}
@@ -0,0 +1,140 @@
+package notifox
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEndpointFailover(t *testing.T) {
+	var mu sync.Mutex
+	var keys []string
+	record := func(r *http.Request) {
+		mu.Lock()
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+		mu.Unlock()
+	}
+
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		record(r)
+		json.NewEncoder(w).Encode(AlertResponse{MessageID: "from-secondary"})
+	}))
+	defer secondary.Close()
+
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithEndpoints(
+		Endpoint{URL: secondary.URL, Priority: 1},
+		Endpoint{URL: primary.URL, Priority: 0},
+	))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	if client.baseURL != primary.URL {
+		t.Errorf("baseURL = %q, want the primary endpoint", client.baseURL)
+	}
+
+	resp, err := client.SendAlert(context.Background(), AlertRequest{Audience: "oncall", Alert: "failover"})
+	if err != nil {
+		t.Fatalf("SendAlert() unexpected error: %v", err)
+	}
+	if resp.MessageID != "from-secondary" {
+		t.Errorf("expected response from secondary, got %q", resp.MessageID)
+	}
+	if len(keys) != 2 || keys[0] != keys[1] {
+		t.Errorf("expected the same idempotency key on both endpoints, got %v", keys)
+	}
+
+	status := client.Endpoints()
+	if status[0].URL != primary.URL || status[0].Healthy || status[0].Failures != 1 {
+		t.Errorf("expected primary to be unhealthy, got %+v", status[0])
+	}
+	if !status[1].Healthy {
+		t.Errorf("expected secondary to be healthy, got %+v", status[1])
+	}
+
+	// While the primary cools down, requests go straight to the secondary.
+	keys = nil
+	if _, err := client.SendAlert(context.Background(), AlertRequest{Audience: "oncall", Alert: "again"}); err != nil {
+		t.Fatalf("SendAlert() unexpected error: %v", err)
+	}
+	if len(keys) != 1 {
+		t.Errorf("expected a single request to the secondary, got %d", len(keys))
+	}
+}
+
+func TestEndpointWeights(t *testing.T) {
+	set := &endpointSet{threshold: 1, cooldown: time.Minute, now: time.Now}
+	for _, e := range []Endpoint{{URL: "a", Weight: 3}, {URL: "b", Weight: 1}, {URL: "c", Priority: 1, Weight: 100}} {
+		set.endpoints = append(set.endpoints, &endpointState{Endpoint: e})
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < 4000; i++ {
+		counts[set.pick().URL]++
+	}
+	if counts["c"] != 0 {
+		t.Errorf("lower priority endpoint used while higher ones are healthy: %v", counts)
+	}
+	if counts["a"] < 2600 || counts["a"] > 3400 {
+		t.Errorf("expected about 3:1 split, got %v", counts)
+	}
+
+	set.report(set.endpoints[0], context.DeadlineExceeded)
+	set.report(set.endpoints[1], context.DeadlineExceeded)
+	if got := set.pick().URL; got != "c" {
+		t.Errorf("expected failover to c, got %s", got)
+	}
+}
+
+func TestHealthProbe(t *testing.T) {
+	var mu sync.Mutex
+	healthy := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/health") {
+			t.Errorf("unexpected probe path %s", r.URL.Path)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if !healthy {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"),
+		WithEndpoints(Endpoint{URL: server.URL}, Endpoint{URL: server.URL + "/backup"}),
+		WithHealthProbe(10*time.Millisecond, "/health"))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	waitFor := func(want bool) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			if client.Endpoints()[0].Healthy == want {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatalf("endpoint never became healthy=%v", want)
+	}
+
+	waitFor(false)
+	mu.Lock()
+	healthy = true
+	mu.Unlock()
+	waitFor(true)
+}
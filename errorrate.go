@@ -0,0 +1,389 @@
+package notifox
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultErrorRateWindow is the default sliding window for request statistics.
+	DefaultErrorRateWindow = time.Minute
+	// DefaultErrorRateBuckets is the default number of buckets the window is split into.
+	DefaultErrorRateBuckets = 12
+	// DefaultErrorRateMinRequests is the default minimum number of requests in the
+	// window before thresholds are evaluated.
+	DefaultErrorRateMinRequests = 20
+)
+
+// ErrorRateThresholds configures when an ErrorRateMonitor fires.
+type ErrorRateThresholds struct {
+	// ErrorRate is the fraction of 5xx responses (0.05 for 5%) that counts as a breach.
+	// Zero disables the check.
+	ErrorRate float64
+	// Latency is the average response time that counts as a breach. Zero disables the check.
+	Latency time.Duration
+	// For is how long a breach must be sustained before alerting.
+	For time.Duration
+	// MinRequests is the minimum number of requests in the window before thresholds
+	// are evaluated. Zero means DefaultErrorRateMinRequests.
+	MinRequests int
+}
+
+// RouteStats are the request statistics for a route over the sliding window.
+type RouteStats struct {
+	Route    string
+	Requests int
+	// Status counts responses by class: index 1 is 1xx through index 5 for 5xx.
+	Status [6]int
+	// ErrorRate is the fraction of 5xx responses.
+	ErrorRate float64
+	// AvgLatency is the average response time.
+	AvgLatency time.Duration
+}
+
+// ErrorRateOption is a function that configures an ErrorRateMonitor.
+type ErrorRateOption func(*ErrorRateMonitor)
+
+// WithErrorRateAudience sets the audience for error rate alerts.
+func WithErrorRateAudience(audience string) ErrorRateOption {
+	return func(m *ErrorRateMonitor) {
+		m.audience = audience
+	}
+}
+
+// WithErrorRateChannel sets the channel for error rate alerts.
+func WithErrorRateChannel(channel Channel) ErrorRateOption {
+	return func(m *ErrorRateMonitor) {
+		m.channel = channel
+	}
+}
+
+// WithErrorRateWindow sets the sliding window and the number of buckets it is split into.
+// If buckets is not positive or the window is too short to split into that many
+// buckets, the defaults are used.
+func WithErrorRateWindow(window time.Duration, buckets int) ErrorRateOption {
+	return func(m *ErrorRateMonitor) {
+		m.window = window
+		m.buckets = buckets
+	}
+}
+
+// WithErrorRateRouteFunc sets how the route is derived from a request. By default the
+// ServeMux pattern is used when available, otherwise the URL path.
+func WithErrorRateRouteFunc(fn func(*http.Request) string) ErrorRateOption {
+	return func(m *ErrorRateMonitor) {
+		m.routeFunc = fn
+	}
+}
+
+// WithErrorRateErrorHandler sets a function called when sending an alert fails.
+// Failed alerts are retried on the next evaluation.
+func WithErrorRateErrorHandler(fn func(route string, err error)) ErrorRateOption {
+	return func(m *ErrorRateMonitor) {
+		m.onError = fn
+	}
+}
+
+// ErrorRateMonitor tracks request counts, status classes and latency per route in
+// a sliding window, and alerts when the error rate or latency stays above its
+// threshold for a sustained period. A resolved alert is sent once it recovers,
+// or once the route gets no requests for a whole window.
+//
+// Wrap handlers with Middleware and call Run to evaluate the thresholds.
+type ErrorRateMonitor struct {
+	sender     AlertSender
+	thresholds ErrorRateThresholds
+	audience   string
+	channel    Channel
+	window     time.Duration
+	buckets    int
+	routeFunc  func(*http.Request) string
+	onError    func(route string, err error)
+	now        func() time.Time
+
+	mu     sync.Mutex
+	routes map[string]*routeWindow
+}
+
+// routeWindow is a ring of time buckets for one route plus its alert state.
+type routeWindow struct {
+	buckets []routeBucket
+	// breachSince is when the current breach started; zero if not breaching.
+	breachSince time.Time
+	// firing is set once an alert has been sent for the current breach.
+	firing bool
+}
+
+type routeBucket struct {
+	// start is the beginning of the bucket's time slot.
+	start    time.Time
+	requests int
+	status   [6]int
+	latency  time.Duration
+}
+
+// NewErrorRateMonitor creates an error rate monitor that alerts through sender.
+func NewErrorRateMonitor(sender AlertSender, thresholds ErrorRateThresholds, opts ...ErrorRateOption) *ErrorRateMonitor {
+	if thresholds.MinRequests <= 0 {
+		thresholds.MinRequests = DefaultErrorRateMinRequests
+	}
+
+	m := &ErrorRateMonitor{
+		sender:     sender,
+		thresholds: thresholds,
+		window:     DefaultErrorRateWindow,
+		buckets:    DefaultErrorRateBuckets,
+		routeFunc:  requestRoute,
+		now:        time.Now,
+		routes:     make(map[string]*routeWindow),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	if m.buckets <= 0 || m.window/time.Duration(m.buckets) <= 0 {
+		m.window = DefaultErrorRateWindow
+		m.buckets = DefaultErrorRateBuckets
+	}
+
+	return m
+}
+
+// Middleware wraps next and records the status and latency of every request.
+func (m *ErrorRateMonitor) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := m.now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			if v := recover(); v != nil {
+				// Count the panic as a 500, then let outer middleware handle it.
+				m.Record(m.routeFunc(r), http.StatusInternalServerError, m.now().Sub(start))
+				panic(v)
+			}
+			m.Record(m.routeFunc(r), sw.status, m.now().Sub(start))
+		}()
+
+		next.ServeHTTP(sw, r)
+	})
+}
+
+// Record adds a single request to the statistics for route.
+func (m *ErrorRateMonitor) Record(route string, status int, latency time.Duration) {
+	class := status / 100
+	if class < 1 || class > 5 {
+		class = 5
+	}
+
+	now := m.now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rw, ok := m.routes[route]
+	if !ok {
+		rw = &routeWindow{buckets: make([]routeBucket, m.buckets)}
+		m.routes[route] = rw
+	}
+
+	b := m.bucket(rw, now)
+	b.requests++
+	b.status[class]++
+	b.latency += latency
+}
+
+// bucket returns the bucket for now, resetting it if it holds an older slot.
+func (m *ErrorRateMonitor) bucket(rw *routeWindow, now time.Time) *routeBucket {
+	width := m.window / time.Duration(m.buckets)
+	start := now.Truncate(width)
+	b := &rw.buckets[int(start.UnixNano()/int64(width))%m.buckets]
+	if !b.start.Equal(start) {
+		*b = routeBucket{start: start}
+	}
+	return b
+}
+
+// Stats returns the current statistics for every route, sorted by route.
+func (m *ErrorRateMonitor) Stats() []RouteStats {
+	now := m.now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := make([]RouteStats, 0, len(m.routes))
+	for route, rw := range m.routes {
+		stats = append(stats, m.stats(route, rw, now))
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Route < stats[j].Route })
+	return stats
+}
+
+// stats sums the buckets of rw that fall within the window ending at now.
+func (m *ErrorRateMonitor) stats(route string, rw *routeWindow, now time.Time) RouteStats {
+	s := RouteStats{Route: route}
+	var latency time.Duration
+	for _, b := range rw.buckets {
+		if b.requests == 0 || now.Sub(b.start) >= m.window {
+			continue
+		}
+		s.Requests += b.requests
+		latency += b.latency
+		for i, n := range b.status {
+			s.Status[i] += n
+		}
+	}
+	if s.Requests > 0 {
+		s.ErrorRate = float64(s.Status[5]) / float64(s.Requests)
+		s.AvgLatency = latency / time.Duration(s.Requests)
+	}
+	return s
+}
+
+// Run evaluates the thresholds once per bucket width until ctx is done.
+// It returns an error straight away if no audience is set.
+func (m *ErrorRateMonitor) Run(ctx context.Context) error {
+	if m.audience == "" {
+		return fmt.Errorf("audience cannot be empty (use WithErrorRateAudience)")
+	}
+
+	ticker := time.NewTicker(m.window / time.Duration(m.buckets))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			m.evaluate(ctx)
+		}
+	}
+}
+
+// routeAlert is an alert decided under the lock and sent outside it.
+type routeAlert struct {
+	route    string
+	resolved bool
+	msg      string
+}
+
+// evaluate updates the breach state of every route and sends firing and resolved alerts.
+func (m *ErrorRateMonitor) evaluate(ctx context.Context) {
+	now := m.now()
+
+	m.mu.Lock()
+	var pending []routeAlert
+	for route, rw := range m.routes {
+		s := m.stats(route, rw, now)
+		if s.Requests == 0 {
+			// A route with no traffic left in the window isn't failing any more,
+			// so resolve its alert and forget it once resolved.
+			if rw.firing {
+				msg := fmt.Sprintf("Resolved %s: no requests in %s", route, m.window)
+				pending = append(pending, routeAlert{route: route, resolved: true, msg: msg})
+			} else {
+				delete(m.routes, route)
+			}
+			continue
+		}
+		if s.Requests < m.thresholds.MinRequests {
+			// Too few requests to tell whether the route recovered, so keep its alert state.
+			continue
+		}
+		reason := m.breach(s)
+
+		switch {
+		case reason != "":
+			if rw.breachSince.IsZero() {
+				rw.breachSince = now
+			}
+			if !rw.firing && now.Sub(rw.breachSince) >= m.thresholds.For {
+				msg := fmt.Sprintf("%s: %s for %s (%d requests in %s)",
+					route, reason, now.Sub(rw.breachSince).Round(time.Second), s.Requests, m.window)
+				pending = append(pending, routeAlert{route: route, msg: msg})
+			}
+		case rw.firing:
+			msg := fmt.Sprintf("Resolved %s: error rate %.1f%%, avg latency %s",
+				route, s.ErrorRate*100, s.AvgLatency.Round(time.Millisecond))
+			pending = append(pending, routeAlert{route: route, resolved: true, msg: msg})
+		default:
+			rw.breachSince = time.Time{}
+		}
+	}
+	m.mu.Unlock()
+
+	sort.Slice(pending, func(i, j int) bool { return pending[i].route < pending[j].route })
+
+	for _, a := range pending {
+		_, err := m.sender.SendAlert(ctx, AlertRequest{Audience: m.audience, Channel: m.channel, Alert: a.msg})
+		if err != nil {
+			if m.onError != nil {
+				m.onError(a.route, err)
+			}
+			continue
+		}
+
+		m.mu.Lock()
+		if rw, ok := m.routes[a.route]; ok {
+			rw.firing = !a.resolved
+			if a.resolved {
+				rw.breachSince = time.Time{}
+			}
+		}
+		m.mu.Unlock()
+	}
+}
+
+// breach returns why s breaches the thresholds, or "" if it doesn't.
+func (m *ErrorRateMonitor) breach(s RouteStats) string {
+	if m.thresholds.ErrorRate > 0 && s.ErrorRate >= m.thresholds.ErrorRate {
+		return fmt.Sprintf("error rate %.1f%% above %.1f%%", s.ErrorRate*100, m.thresholds.ErrorRate*100)
+	}
+	if m.thresholds.Latency > 0 && s.AvgLatency >= m.thresholds.Latency {
+		return fmt.Sprintf("avg latency %s above %s", s.AvgLatency.Round(time.Millisecond), m.thresholds.Latency)
+	}
+	return ""
+}
+
+// statusWriter records the response status code and whether it has been written.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Flush implements http.Flusher for handlers that type-assert it.
+func (w *statusWriter) Flush() {
+	w.wroteHeader = true
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack implements http.Hijacker for handlers that type-assert it.
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		w.wroteHeader = true
+	}
+	return conn, rw, err
+}
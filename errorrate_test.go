@@ -0,0 +1,163 @@
+package notifox
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestErrorRateMonitor(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sender := &recordingSender{}
+	m := NewErrorRateMonitor(sender, ErrorRateThresholds{
+		ErrorRate:   0.05,
+		For:         10 * time.Second,
+		MinRequests: 10,
+	}, WithErrorRateAudience("oncall"))
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	record := func(ok, failed int) {
+		for i := 0; i < ok; i++ {
+			m.Record("/checkout", http.StatusOK, 10*time.Millisecond)
+		}
+		for i := 0; i < failed; i++ {
+			m.Record("/checkout", http.StatusBadGateway, 10*time.Millisecond)
+		}
+	}
+
+	record(90, 10)
+	m.evaluate(ctx)
+	if got := len(sender.requests()); got != 0 {
+		t.Fatalf("expected no alert before breach is sustained, got %d", got)
+	}
+
+	now = now.Add(10 * time.Second)
+	m.evaluate(ctx)
+	m.evaluate(ctx)
+	reqs := sender.requests()
+	if len(reqs) != 1 {
+		t.Fatalf("expected 1 alert once breach is sustained, got %d", len(reqs))
+	}
+	if !strings.Contains(reqs[0].Alert, "/checkout: error rate 10.0%") {
+		t.Errorf("unexpected alert %q", reqs[0].Alert)
+	}
+
+	// Traffic dropping below MinRequests is not a recovery.
+	now = now.Add(DefaultErrorRateWindow)
+	record(5, 0)
+	m.evaluate(ctx)
+	if got := len(sender.requests()); got != 1 {
+		t.Fatalf("expected no resolved alert with too few requests, got %d alerts", got)
+	}
+
+	// Once there are enough healthy requests, the alert resolves.
+	record(95, 0)
+	m.evaluate(ctx)
+	reqs = sender.requests()
+	if len(reqs) != 2 {
+		t.Fatalf("expected a resolved alert, got %d alerts", len(reqs))
+	}
+	if !strings.HasPrefix(reqs[1].Alert, "Resolved /checkout") {
+		t.Errorf("unexpected resolved alert %q", reqs[1].Alert)
+	}
+}
+
+func TestErrorRateMonitorResolvesIdleRoute(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sender := &recordingSender{}
+	m := NewErrorRateMonitor(sender, ErrorRateThresholds{ErrorRate: 0.5, MinRequests: 1}, WithErrorRateAudience("oncall"))
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	m.Record("/checkout", http.StatusBadGateway, 10*time.Millisecond)
+	m.evaluate(ctx)
+
+	// The route gets no more traffic, so the alert resolves once the window empties.
+	now = now.Add(2 * DefaultErrorRateWindow)
+	m.evaluate(ctx)
+	reqs := sender.requests()
+	if len(reqs) != 2 || reqs[1].Alert != "Resolved /checkout: no requests in 1m0s" {
+		t.Fatalf("expected a resolved alert for the idle route, got %+v", reqs)
+	}
+
+	m.evaluate(ctx)
+	if len(m.Stats()) != 0 {
+		t.Errorf("expected the resolved idle route to be forgotten, got %+v", m.Stats())
+	}
+}
+
+func TestErrorRateMonitorRunRequiresAudience(t *testing.T) {
+	m := NewErrorRateMonitor(&recordingSender{}, ErrorRateThresholds{ErrorRate: 0.5})
+	if err := m.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "audience") {
+		t.Errorf("Run() = %v, want audience error", err)
+	}
+}
+
+func TestErrorRateMonitorLatency(t *testing.T) {
+	sender := &recordingSender{}
+	m := NewErrorRateMonitor(sender, ErrorRateThresholds{Latency: 500 * time.Millisecond, MinRequests: 1})
+
+	for i := 0; i < 5; i++ {
+		m.Record("/slow", http.StatusOK, time.Second)
+	}
+	m.evaluate(context.Background())
+
+	reqs := sender.requests()
+	if len(reqs) != 1 || !strings.Contains(reqs[0].Alert, "avg latency 1s") {
+		t.Errorf("expected latency alert, got %v", reqs)
+	}
+}
+
+func TestErrorRateMonitorInvalidWindow(t *testing.T) {
+	for _, opt := range []ErrorRateOption{
+		WithErrorRateWindow(time.Minute, 0),
+		WithErrorRateWindow(10*time.Nanosecond, 20),
+	} {
+		m := NewErrorRateMonitor(&recordingSender{}, ErrorRateThresholds{ErrorRate: 0.1}, opt)
+		if m.window != DefaultErrorRateWindow || m.buckets != DefaultErrorRateBuckets {
+			t.Errorf("expected defaults for an invalid window, got %s/%d", m.window, m.buckets)
+		}
+		m.Record("/", http.StatusOK, time.Millisecond)
+	}
+}
+
+func TestErrorRateMonitorMiddleware(t *testing.T) {
+	m := NewErrorRateMonitor(&recordingSender{}, ErrorRateThresholds{ErrorRate: 0.5})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") == "missing" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.PathValue("id") == "broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+	handler := m.Middleware(mux)
+
+	for _, id := range []string{"1", "2", "missing", "broken"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/items/"+id, nil))
+	}
+
+	stats := m.Stats()
+	if len(stats) != 1 {
+		t.Fatalf("expected stats for 1 route, got %d", len(stats))
+	}
+	s := stats[0]
+	if s.Route != "GET /items/{id}" {
+		t.Errorf("Route = %q, want %q", s.Route, "GET /items/{id}")
+	}
+	if s.Requests != 4 || s.Status[2] != 2 || s.Status[4] != 1 || s.Status[5] != 1 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+	if s.ErrorRate != 0.25 {
+		t.Errorf("ErrorRate = %v, want 0.25", s.ErrorRate)
+	}
+}
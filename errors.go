@@ -1,19 +1,66 @@
 package notifox
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// Sentinel errors for use with errors.Is. The concrete error types below match
+// the relevant sentinel, so callers can check the kind of failure without a type switch:
+//
+//	if errors.Is(err, notifox.ErrRateLimited) { ... }
+var (
+	// ErrUnauthorized matches NotifoxAuthenticationError.
+	ErrUnauthorized = errors.New("notifox: unauthorized")
+	// ErrRateLimited matches NotifoxRateLimitError.
+	ErrRateLimited = errors.New("notifox: rate limited")
+	// ErrInsufficientBalance matches NotifoxInsufficientBalanceError.
+	ErrInsufficientBalance = errors.New("notifox: insufficient balance")
+	// ErrInvalidAudience matches a NotifoxAPIError rejecting the request's audience.
+	ErrInvalidAudience = errors.New("notifox: invalid audience")
+)
+
+// Machine-readable error codes returned by the API in ErrorResponse.Code.
+const (
+	CodeInvalidAudience     = "invalid_audience"
+	CodeAudienceNotFound    = "audience_not_found"
+	CodeAudienceUnverified  = "audience_not_verified"
+	CodeInsufficientBalance = "insufficient_balance"
+	CodeRateLimited         = "rate_limited"
+)
+
+// RequestIDHeader is the response header carrying the server's request ID.
+const RequestIDHeader = "X-Request-Id"
+
 // NotifoxError is the base error type for all Notifox errors.
 type NotifoxError struct {
 	Message string
+	// Code is the machine-readable error code from the API, if any.
+	Code string
+	// RequestID is the server's ID for the failed request, for support tickets.
+	RequestID string
+	// Header holds the response headers, if a response was received.
+	Header http.Header
+	// Attempts is the number of attempts made before giving up. For SendAlert it
+	// includes hedged attempts.
+	Attempts int
 }
 
 func (e *NotifoxError) Error() string {
 	return e.Message
 }
 
+// base returns e; it gives access to the shared fields of any Notifox error.
+func (e *NotifoxError) base() *NotifoxError {
+	return e
+}
+
 // NotifoxAuthenticationError represents authentication failures (401/403).
 type NotifoxAuthenticationError struct {
 	NotifoxError
@@ -28,10 +75,24 @@ func (e *NotifoxAuthenticationError) Error() string {
 	return fmt.Sprintf("authentication failed (%d)", e.StatusCode)
 }
 
+// Is reports whether target is ErrUnauthorized.
+func (e *NotifoxAuthenticationError) Is(target error) bool {
+	return target == ErrUnauthorized
+}
+
+// Temporary reports false: the request will keep failing until the key is fixed.
+func (e *NotifoxAuthenticationError) Temporary() bool { return false }
+
+// Retryable reports false.
+func (e *NotifoxAuthenticationError) Retryable() bool { return false }
+
 // NotifoxRateLimitError represents rate limit exceeded errors (429).
 type NotifoxRateLimitError struct {
 	NotifoxError
+	StatusCode   int
 	ResponseText string
+	// RetryAfter is how long the server asked to wait before retrying, if it said.
+	RetryAfter time.Duration
 }
 
 func (e *NotifoxRateLimitError) Error() string {
@@ -41,9 +102,21 @@ func (e *NotifoxRateLimitError) Error() string {
 	return "rate limit exceeded"
 }
 
+// Is reports whether target is ErrRateLimited.
+func (e *NotifoxRateLimitError) Is(target error) bool {
+	return target == ErrRateLimited
+}
+
+// Temporary reports true: the request can succeed once the rate limit resets.
+func (e *NotifoxRateLimitError) Temporary() bool { return true }
+
+// Retryable reports false: the client does not retry immediately; wait for RetryAfter.
+func (e *NotifoxRateLimitError) Retryable() bool { return false }
+
 // NotifoxInsufficientBalanceError represents insufficient balance errors (402).
 type NotifoxInsufficientBalanceError struct {
 	NotifoxError
+	StatusCode   int
 	ResponseText string
 }
 
@@ -54,6 +127,17 @@ func (e *NotifoxInsufficientBalanceError) Error() string {
 	return "insufficient balance"
 }
 
+// Is reports whether target is ErrInsufficientBalance.
+func (e *NotifoxInsufficientBalanceError) Is(target error) bool {
+	return target == ErrInsufficientBalance
+}
+
+// Temporary reports false: the request will keep failing until the account is topped up.
+func (e *NotifoxInsufficientBalanceError) Temporary() bool { return false }
+
+// Retryable reports false.
+func (e *NotifoxInsufficientBalanceError) Retryable() bool { return false }
+
 // NotifoxAPIError represents general API errors.
 type NotifoxAPIError struct {
 	NotifoxError
@@ -68,47 +152,192 @@ func (e *NotifoxAPIError) Error() string {
 	return fmt.Sprintf("API error (%d)", e.StatusCode)
 }
 
+// Is reports whether target is ErrInvalidAudience and the API rejected the audience.
+func (e *NotifoxAPIError) Is(target error) bool {
+	if target != ErrInvalidAudience {
+		return false
+	}
+	switch e.Code {
+	case CodeInvalidAudience, CodeAudienceNotFound, CodeAudienceUnverified:
+		return true
+	case "":
+		// Older API versions only describe the problem in the message.
+		return e.StatusCode >= 400 && e.StatusCode < 500 && strings.Contains(strings.ToLower(e.ResponseText), "audience")
+	default:
+		return false
+	}
+}
+
+// Temporary reports whether the error is a server error or timeout that may clear on its own.
+func (e *NotifoxAPIError) Temporary() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusRequestTimeout
+}
+
+// Retryable reports whether the request can be retried: server errors and timeouts are,
+// other client errors are not.
+func (e *NotifoxAPIError) Retryable() bool {
+	return e.Temporary()
+}
+
+// CancelReason explains why a request was cut short by a timeout or cancellation.
+type CancelReason string
+
+const (
+	// ReasonAttemptTimeout means a single attempt took longer than the attempt
+	// timeout (WithAttemptTimeout or WithTimeout). The request may be retried.
+	ReasonAttemptTimeout CancelReason = "attempt timeout"
+	// ReasonTotalTimeout means the request, including retries, took longer than
+	// WithTotalTimeout.
+	ReasonTotalTimeout CancelReason = "total timeout"
+	// ReasonDeadline means the caller's context deadline passed.
+	ReasonDeadline CancelReason = "deadline exceeded"
+	// ReasonCanceled means the caller's context was canceled.
+	ReasonCanceled CancelReason = "canceled"
+)
+
 // NotifoxConnectionError represents network/connection errors.
 type NotifoxConnectionError struct {
 	NotifoxError
 	Err error
+	// Reason is set when the request was cut short by a timeout or cancellation.
+	Reason CancelReason
 }
 
 func (e *NotifoxConnectionError) Error() string {
+	msg := "connection failed"
+	if e.Reason != "" {
+		msg += " (" + string(e.Reason) + ")"
+	}
 	if e.Err != nil {
-		return fmt.Sprintf("connection failed: %v", e.Err)
+		return fmt.Sprintf("%s: %v", msg, e.Err)
 	}
-	return "connection failed"
+	return msg
 }
 
 func (e *NotifoxConnectionError) Unwrap() error {
 	return e.Err
 }
 
-// parseError creates the appropriate error type based on the HTTP status code.
-func parseError(statusCode int, responseText string) error {
+// Temporary reports true: network failures usually clear on their own.
+func (e *NotifoxConnectionError) Temporary() bool { return true }
+
+// Retryable reports whether the request can be retried. It is false when the
+// caller's context was canceled or its deadline passed, or the total timeout expired.
+func (e *NotifoxConnectionError) Retryable() bool {
+	switch e.Reason {
+	case ReasonAttemptTimeout:
+		return true
+	case ReasonTotalTimeout, ReasonDeadline, ReasonCanceled:
+		return false
+	}
+	return !errors.Is(e.Err, context.Canceled) && !errors.Is(e.Err, context.DeadlineExceeded)
+}
+
+// IsRetryable reports whether err is a Notifox error that the client would retry.
+func IsRetryable(err error) bool {
+	var r interface{ Retryable() bool }
+	return errors.As(err, &r) && r.Retryable()
+}
+
+// IsTemporary reports whether err is a Notifox error that may clear on its own,
+// so the same request could succeed later.
+func IsTemporary(err error) bool {
+	var t interface{ Temporary() bool }
+	return errors.As(err, &t) && t.Temporary()
+}
+
+// setAttempts records the number of attempts on err, if it is a Notifox error.
+func setAttempts(err error, attempts int) {
+	var e interface{ base() *NotifoxError }
+	if errors.As(err, &e) {
+		e.base().Attempts = attempts
+	}
+}
+
+// errorStatus returns the HTTP status of a Notifox error response, or 0 if err
+// isn't one.
+func errorStatus(err error) int {
+	var authErr *NotifoxAuthenticationError
+	var rateErr *NotifoxRateLimitError
+	var balanceErr *NotifoxInsufficientBalanceError
+	var apiErr *NotifoxAPIError
+	switch {
+	case errors.As(err, &authErr):
+		return authErr.StatusCode
+	case errors.As(err, &rateErr):
+		return rateErr.StatusCode
+	case errors.As(err, &balanceErr):
+		return balanceErr.StatusCode
+	case errors.As(err, &apiErr):
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// parseError creates the appropriate error type from an HTTP error response.
+// The body is parsed as an ErrorResponse when possible; otherwise (e.g. the plain
+// text "Unauthorized" returned for 401) it is used as is.
+func parseError(statusCode int, header http.Header, body []byte) error {
+	responseText := string(body)
+	base := NotifoxError{
+		Header:    header,
+		RequestID: header.Get(RequestIDHeader),
+	}
+
+	var errorResp ErrorResponse
+	if err := json.Unmarshal(body, &errorResp); err == nil && errorResp.Error != "" {
+		responseText = errorResp.Error
+		base.Code = errorResp.Code
+		if errorResp.RequestID != "" {
+			base.RequestID = errorResp.RequestID
+		}
+	}
+
 	switch statusCode {
 	case http.StatusUnauthorized, http.StatusForbidden:
+		base.Message = "authentication failed"
 		return &NotifoxAuthenticationError{
-			NotifoxError: NotifoxError{Message: "authentication failed"},
+			NotifoxError: base,
 			StatusCode:   statusCode,
 			ResponseText: responseText,
 		}
 	case http.StatusPaymentRequired: // 402
+		base.Message = "insufficient balance"
 		return &NotifoxInsufficientBalanceError{
-			NotifoxError: NotifoxError{Message: "insufficient balance"},
+			NotifoxError: base,
+			StatusCode:   statusCode,
 			ResponseText: responseText,
 		}
 	case http.StatusTooManyRequests: // 429
+		base.Message = "rate limit exceeded"
 		return &NotifoxRateLimitError{
-			NotifoxError: NotifoxError{Message: "rate limit exceeded"},
+			NotifoxError: base,
+			StatusCode:   statusCode,
 			ResponseText: responseText,
+			RetryAfter:   parseRetryAfter(header.Get("Retry-After")),
 		}
 	default:
+		base.Message = "API error"
 		return &NotifoxAPIError{
-			NotifoxError: NotifoxError{Message: "API error"},
+			NotifoxError: base,
 			StatusCode:   statusCode,
 			ResponseText: responseText,
 		}
 	}
 }
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,141 @@
+package notifox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestErrorSentinels(t *testing.T) {
+	tests := []struct {
+		name          string
+		statusCode    int
+		body          string
+		wantSentinel  error
+		wantRetryable bool
+		wantTemporary bool
+	}{
+		{
+			name:         "unauthorized",
+			statusCode:   http.StatusUnauthorized,
+			body:         "Unauthorized",
+			wantSentinel: ErrUnauthorized,
+		},
+		{
+			name:          "rate limited",
+			statusCode:    http.StatusTooManyRequests,
+			body:          `{"error":"slow down","code":"rate_limited"}`,
+			wantSentinel:  ErrRateLimited,
+			wantTemporary: true,
+		},
+		{
+			name:         "insufficient balance",
+			statusCode:   http.StatusPaymentRequired,
+			body:         `{"error":"top up your account"}`,
+			wantSentinel: ErrInsufficientBalance,
+		},
+		{
+			name:         "invalid audience by code",
+			statusCode:   http.StatusBadRequest,
+			body:         `{"error":"no such audience","code":"audience_not_found"}`,
+			wantSentinel: ErrInvalidAudience,
+		},
+		{
+			name:         "invalid audience by message",
+			statusCode:   http.StatusBadRequest,
+			body:         `{"error":"audience is not verified"}`,
+			wantSentinel: ErrInvalidAudience,
+		},
+		{
+			name:          "server error",
+			statusCode:    http.StatusBadGateway,
+			body:          `{"error":"upstream failed"}`,
+			wantRetryable: true,
+			wantTemporary: true,
+		},
+	}
+
+	sentinels := []error{ErrUnauthorized, ErrRateLimited, ErrInsufficientBalance, ErrInvalidAudience}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := parseError(tt.statusCode, http.Header{}, []byte(tt.body))
+
+			for _, sentinel := range sentinels {
+				if got, want := errors.Is(err, sentinel), sentinel == tt.wantSentinel; got != want {
+					t.Errorf("errors.Is(err, %v) = %v, want %v", sentinel, got, want)
+				}
+			}
+			if got := IsRetryable(err); got != tt.wantRetryable {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.wantRetryable)
+			}
+			if got := IsTemporary(err); got != tt.wantTemporary {
+				t.Errorf("IsTemporary() = %v, want %v", got, tt.wantTemporary)
+			}
+		})
+	}
+}
+
+func TestErrorDetails(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set(RequestIDHeader, "req-abc")
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":"rate limit exceeded","code":"rate_limited"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	_, err = client.SendAlert(context.Background(), AlertRequest{Audience: "test-user", Alert: "Test alert"})
+
+	var rateErr *NotifoxRateLimitError
+	if !errors.As(err, &rateErr) {
+		t.Fatalf("expected NotifoxRateLimitError, got %T: %v", err, err)
+	}
+	if rateErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("StatusCode = %d, want %d", rateErr.StatusCode, http.StatusTooManyRequests)
+	}
+	if rateErr.RequestID != "req-abc" {
+		t.Errorf("RequestID = %q, want %q", rateErr.RequestID, "req-abc")
+	}
+	if rateErr.Code != CodeRateLimited {
+		t.Errorf("Code = %q, want %q", rateErr.Code, CodeRateLimited)
+	}
+	if rateErr.RetryAfter != 30*time.Second {
+		t.Errorf("RetryAfter = %v, want 30s", rateErr.RetryAfter)
+	}
+	if rateErr.Attempts != 1 || attempts != 1 {
+		t.Errorf("Attempts = %d (server saw %d), want 1", rateErr.Attempts, attempts)
+	}
+}
+
+func TestErrorAttemptsAfterRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL), WithMaxRetries(2))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	_, err = client.SendAlert(context.Background(), AlertRequest{Audience: "test-user", Alert: "Test alert"})
+
+	var apiErr *NotifoxAPIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected NotifoxAPIError, got %T: %v", err, err)
+	}
+	if apiErr.Attempts != 3 {
+		t.Errorf("Attempts = %d, want 3", apiErr.Attempts)
+	}
+}
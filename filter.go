@@ -0,0 +1,206 @@
+package notifox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// ErrContentRejected is returned by SendAlert when the content filter rejects an alert.
+var ErrContentRejected = errors.New("notifox: alert rejected by content filter")
+
+// FilterAction is what a content filter rule does with a match.
+type FilterAction string
+
+const (
+	// FilterMask replaces each match with the rule's mask.
+	FilterMask FilterAction = "mask"
+	// FilterReject refuses to send the alert.
+	FilterReject FilterAction = "reject"
+	// FilterWarn sends the alert unchanged and reports the match.
+	FilterWarn FilterAction = "warn"
+)
+
+// FilterRule detects one kind of sensitive content.
+type FilterRule struct {
+	Name    string
+	Pattern *regexp.Regexp
+	// Validate, if set, filters out false positives, e.g. a Luhn check for card numbers.
+	Validate func(match string) bool
+	Action   FilterAction
+	// Mask replaces matches when Action is FilterMask. Defaults to "[<Name>]".
+	Mask string
+}
+
+// Redaction reports what a rule found in an alert. It never includes the matched text.
+type Redaction struct {
+	Rule   string
+	Action FilterAction
+	Count  int
+}
+
+// Built-in rule patterns.
+var (
+	creditCardPattern  = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	jwtPattern         = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`)
+	awsKeyPattern      = regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b|(?i:aws_secret_access_key)\s*[=:]\s*[A-Za-z0-9/+=]{40}`)
+	bearerTokenPattern = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/-]+=*`)
+)
+
+// EmailRule detects email addresses.
+func EmailRule(action FilterAction) FilterRule {
+	return FilterRule{Name: "email", Pattern: emailPattern, Action: action}
+}
+
+// PhoneRule detects international (+44 20 7946 0958) and North American phone numbers.
+func PhoneRule(action FilterAction) FilterRule {
+	return FilterRule{Name: "phone", Pattern: phonePattern, Action: action}
+}
+
+// CreditCardRule detects card numbers of 13 to 19 digits that pass the Luhn check.
+func CreditCardRule(action FilterAction) FilterRule {
+	return FilterRule{Name: "credit_card", Pattern: creditCardPattern, Validate: luhnValid, Action: action}
+}
+
+// JWTRule detects JSON Web Tokens.
+func JWTRule(action FilterAction) FilterRule {
+	return FilterRule{Name: "jwt", Pattern: jwtPattern, Action: action}
+}
+
+// AWSKeyRule detects AWS access key IDs and secret access key assignments.
+func AWSKeyRule(action FilterAction) FilterRule {
+	return FilterRule{Name: "aws_key", Pattern: awsKeyPattern, Action: action}
+}
+
+// BearerTokenRule detects "Bearer <token>" credentials.
+func BearerTokenRule(action FilterAction) FilterRule {
+	return FilterRule{Name: "bearer_token", Pattern: bearerTokenPattern, Action: action}
+}
+
+// RegexRule returns a rule for a custom pattern.
+func RegexRule(name, pattern string, action FilterAction) (FilterRule, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return FilterRule{}, fmt.Errorf("invalid pattern for rule %q: %w", name, err)
+	}
+	return FilterRule{Name: name, Pattern: re, Action: action}, nil
+}
+
+// DefaultFilterRules returns the built-in rules: credentials are rejected, and
+// card numbers, email addresses and phone numbers are masked.
+func DefaultFilterRules() []FilterRule {
+	return []FilterRule{
+		BearerTokenRule(FilterReject),
+		JWTRule(FilterReject),
+		AWSKeyRule(FilterReject),
+		CreditCardRule(FilterMask),
+		EmailRule(FilterMask),
+		PhoneRule(FilterMask),
+	}
+}
+
+// ContentFilter checks alert text against a list of rules, in order.
+type ContentFilter struct {
+	rules []FilterRule
+}
+
+// NewContentFilter creates a filter. With no rules it uses DefaultFilterRules.
+func NewContentFilter(rules ...FilterRule) *ContentFilter {
+	if len(rules) == 0 {
+		rules = DefaultFilterRules()
+	}
+	return &ContentFilter{rules: rules}
+}
+
+// Apply runs the rules over text and returns the masked text and what was found.
+// If a FilterReject rule matches, the error wraps ErrContentRejected.
+func (f *ContentFilter) Apply(text string) (string, []Redaction, error) {
+	var redactions []Redaction
+	var rejected []string
+
+	for _, rule := range f.rules {
+		count := 0
+		masked := rule.Pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if rule.Validate != nil && !rule.Validate(match) {
+				return match
+			}
+			count++
+			if rule.Action != FilterMask {
+				return match
+			}
+			if rule.Mask != "" {
+				return rule.Mask
+			}
+			return "[" + rule.Name + "]"
+		})
+		if count == 0 {
+			continue
+		}
+
+		redactions = append(redactions, Redaction{Rule: rule.Name, Action: rule.Action, Count: count})
+		switch rule.Action {
+		case FilterMask:
+			text = masked
+		case FilterReject:
+			rejected = append(rejected, rule.Name)
+		}
+	}
+
+	if len(rejected) > 0 {
+		return "", redactions, fmt.Errorf("%w: found %s", ErrContentRejected, strings.Join(rejected, ", "))
+	}
+	return text, redactions, nil
+}
+
+// WithContentFilter checks every alert with f before it is sent. Masked content
+// is replaced in the alert text, and AlertResponse.Redactions reports what was found.
+func WithContentFilter(f *ContentFilter) ClientOption {
+	return func(c *Client) {
+		c.contentFilter = f
+	}
+}
+
+// filterAlert applies the client's content filter to req.Alert.
+func (c *Client) filterAlert(ctx context.Context, req *AlertRequest) ([]Redaction, error) {
+	if c.contentFilter == nil {
+		return nil, nil
+	}
+
+	text, redactions, err := c.contentFilter.Apply(req.Alert)
+	for _, r := range redactions {
+		if r.Action == FilterWarn && c.logger != nil {
+			c.logger.LogAttrs(ctx, slog.LevelWarn, "notifox alert contains sensitive content",
+				slog.String("rule", r.Rule), slog.Int("count", r.Count), slog.String("audience", req.Audience))
+		}
+	}
+	if err != nil {
+		return redactions, err
+	}
+
+	req.Alert = text
+	return redactions, nil
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum.
+func luhnValid(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		ch := s[i]
+		if ch < '0' || ch > '9' {
+			continue
+		}
+		d := int(ch - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && n <= 19 && sum%10 == 0
+}
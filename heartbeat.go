@@ -0,0 +1,291 @@
+package notifox
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultHeartbeatCheckInterval is how often a HeartbeatMonitor checks for missed heartbeats.
+const DefaultHeartbeatCheckInterval = 5 * time.Second
+
+// HeartbeatConfig configures a single named heartbeat.
+type HeartbeatConfig struct {
+	// Interval is the expected time between beats.
+	Interval time.Duration
+	// Grace is extra time allowed after Interval before the heartbeat counts as missed.
+	Grace time.Duration
+	// Audience overrides the monitor's audience for this heartbeat.
+	Audience string
+	// Channel overrides the monitor's channel for this heartbeat.
+	Channel Channel
+}
+
+// HeartbeatOption is a function that configures a HeartbeatMonitor.
+type HeartbeatOption func(*HeartbeatMonitor)
+
+// WithHeartbeatAudience sets the default audience for heartbeat alerts.
+func WithHeartbeatAudience(audience string) HeartbeatOption {
+	return func(m *HeartbeatMonitor) {
+		m.audience = audience
+	}
+}
+
+// WithHeartbeatChannel sets the default channel for heartbeat alerts.
+func WithHeartbeatChannel(channel Channel) HeartbeatOption {
+	return func(m *HeartbeatMonitor) {
+		m.channel = channel
+	}
+}
+
+// WithHeartbeatCheckInterval sets how often the monitor checks for missed heartbeats.
+// Non-positive values use DefaultHeartbeatCheckInterval.
+func WithHeartbeatCheckInterval(interval time.Duration) HeartbeatOption {
+	return func(m *HeartbeatMonitor) {
+		if interval <= 0 {
+			interval = DefaultHeartbeatCheckInterval
+		}
+		m.checkInterval = interval
+	}
+}
+
+// WithHeartbeatErrorHandler sets a function called when sending a heartbeat alert fails.
+// Failed alerts are retried on the next check.
+func WithHeartbeatErrorHandler(fn func(name string, err error)) HeartbeatOption {
+	return func(m *HeartbeatMonitor) {
+		m.onError = fn
+	}
+}
+
+// HeartbeatMonitor is a dead man's switch: it alerts when a named heartbeat is not
+// seen within its interval plus grace period, and again when beats resume.
+//
+// Services call Beat in-process, or ping the monitor over HTTP (it implements
+// http.Handler). Alerts are sent from Run, which must be running for the monitor
+// to do anything.
+type HeartbeatMonitor struct {
+	sender        AlertSender
+	audience      string
+	channel       Channel
+	checkInterval time.Duration
+	onError       func(name string, err error)
+	now           func() time.Time
+
+	mu    sync.Mutex
+	beats map[string]*heartbeat
+}
+
+type heartbeat struct {
+	cfg  HeartbeatConfig
+	last time.Time
+	// alerted is set once a missed alert has been sent.
+	alerted bool
+	// alerting is set while a missed alert is being sent.
+	alerting bool
+	// recovered is set when a beat arrives after or during a missed alert, until
+	// the recovery alert is sent.
+	recovered bool
+	// missedAt is when the heartbeat was first found missed.
+	missedAt time.Time
+}
+
+// NewHeartbeatMonitor creates a heartbeat monitor that sends alerts through sender.
+func NewHeartbeatMonitor(sender AlertSender, opts ...HeartbeatOption) *HeartbeatMonitor {
+	m := &HeartbeatMonitor{
+		sender:        sender,
+		checkInterval: DefaultHeartbeatCheckInterval,
+		now:           time.Now,
+		beats:         make(map[string]*heartbeat),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Register adds a named heartbeat. The first interval starts counting at registration.
+// Registering an existing name replaces its configuration and resets it.
+func (m *HeartbeatMonitor) Register(name string, cfg HeartbeatConfig) error {
+	if name == "" {
+		return fmt.Errorf("heartbeat name cannot be empty")
+	}
+	if cfg.Interval <= 0 {
+		return fmt.Errorf("heartbeat interval must be positive")
+	}
+	if cfg.Audience == "" && m.audience == "" {
+		return fmt.Errorf("audience cannot be empty (set HeartbeatConfig.Audience or use WithHeartbeatAudience)")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.beats[name] = &heartbeat{cfg: cfg, last: m.now()}
+	return nil
+}
+
+// Unregister removes a named heartbeat.
+func (m *HeartbeatMonitor) Unregister(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.beats, name)
+}
+
+// Beat records that the named heartbeat was seen.
+func (m *HeartbeatMonitor) Beat(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	hb, ok := m.beats[name]
+	if !ok {
+		return fmt.Errorf("unknown heartbeat %q", name)
+	}
+
+	hb.last = m.now()
+	switch {
+	case hb.alerted || hb.alerting:
+		hb.alerted = false
+		hb.recovered = true
+	case !hb.recovered:
+		// No missed alert went out, so the next outage starts afresh.
+		hb.missedAt = time.Time{}
+	}
+	return nil
+}
+
+// LastBeat returns when the named heartbeat was last seen.
+func (m *HeartbeatMonitor) LastBeat(name string) (time.Time, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	hb, ok := m.beats[name]
+	if !ok {
+		return time.Time{}, false
+	}
+	return hb.last, true
+}
+
+// Run checks heartbeats every check interval until ctx is done.
+func (m *HeartbeatMonitor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			m.check(ctx)
+		}
+	}
+}
+
+// heartbeatAlert is an alert decided under the lock and sent outside it.
+type heartbeatAlert struct {
+	name     string
+	recovery bool
+	req      AlertRequest
+}
+
+// check sends missed and recovery alerts for every heartbeat.
+func (m *HeartbeatMonitor) check(ctx context.Context) {
+	now := m.now()
+
+	m.mu.Lock()
+	var pending []heartbeatAlert
+	for name, hb := range m.beats {
+		switch {
+		case hb.alerting:
+		case hb.recovered:
+			msg := fmt.Sprintf("Heartbeat %q recovered after %s", name, hb.last.Sub(hb.missedAt).Round(time.Second))
+			pending = append(pending, heartbeatAlert{name: name, recovery: true, req: m.request(hb, msg)})
+		case !hb.alerted && now.Sub(hb.last) > hb.cfg.Interval+hb.cfg.Grace:
+			if hb.missedAt.IsZero() {
+				hb.missedAt = now
+			}
+			hb.alerting = true
+			msg := fmt.Sprintf("Heartbeat %q missed: last seen %s ago (expected every %s)", name, now.Sub(hb.last).Round(time.Second), hb.cfg.Interval)
+			pending = append(pending, heartbeatAlert{name: name, req: m.request(hb, msg)})
+		}
+	}
+	m.mu.Unlock()
+
+	sort.Slice(pending, func(i, j int) bool { return pending[i].name < pending[j].name })
+
+	for _, a := range pending {
+		_, err := m.sender.SendAlert(ctx, a.req)
+
+		m.mu.Lock()
+		if hb, ok := m.beats[a.name]; ok {
+			switch {
+			case a.recovery:
+				if err == nil {
+					hb.recovered = false
+					hb.missedAt = time.Time{}
+				}
+			case err != nil:
+				hb.alerting = false
+				if hb.recovered {
+					// A beat arrived while sending; no outage was reported, so
+					// there is nothing to recover from.
+					hb.recovered = false
+					hb.missedAt = time.Time{}
+				}
+			default:
+				hb.alerting = false
+				// If a beat arrived while sending, recovered is set instead.
+				hb.alerted = !hb.recovered
+			}
+		}
+		m.mu.Unlock()
+
+		if err != nil && m.onError != nil {
+			m.onError(a.name, err)
+		}
+	}
+}
+
+func (m *HeartbeatMonitor) request(hb *heartbeat, msg string) AlertRequest {
+	req := AlertRequest{Audience: m.audience, Channel: m.channel, Alert: msg}
+	if hb.cfg.Audience != "" {
+		req.Audience = hb.cfg.Audience
+	}
+	if hb.cfg.Channel != "" {
+		req.Channel = hb.cfg.Channel
+	}
+	return req
+}
+
+// ServeHTTP records a beat for the heartbeat named by the last path segment or the
+// "name" query parameter, so cron jobs can ping it with curl:
+//
+//	curl -fsS https://monitor.internal/heartbeat/nightly-backup
+func (m *HeartbeatMonitor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPost:
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		name = path.Base(r.URL.Path)
+	}
+
+	if err := m.Beat(name); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("OK\n"))
+}
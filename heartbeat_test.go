@@ -0,0 +1,198 @@
+package notifox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingSender is an AlertSender that records requests instead of sending them.
+type recordingSender struct {
+	mu   sync.Mutex
+	reqs []AlertRequest
+	err  error
+}
+
+func (s *recordingSender) SendAlert(ctx context.Context, req AlertRequest) (*AlertResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.reqs = append(s.reqs, req)
+	return &AlertResponse{MessageID: "test-message"}, nil
+}
+
+func (s *recordingSender) requests() []AlertRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]AlertRequest(nil), s.reqs...)
+}
+
+func TestHeartbeatMonitor(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sender := &recordingSender{}
+	m := NewHeartbeatMonitor(sender, WithHeartbeatAudience("oncall"), WithHeartbeatChannel(SMS))
+	m.now = func() time.Time { return now }
+
+	if err := m.Register("backup", HeartbeatConfig{Interval: time.Minute, Grace: 10 * time.Second}); err != nil {
+		t.Fatalf("Register() unexpected error: %v", err)
+	}
+
+	ctx := context.Background()
+
+	now = now.Add(65 * time.Second)
+	m.check(ctx)
+	if got := len(sender.requests()); got != 0 {
+		t.Fatalf("expected no alert within grace period, got %d", got)
+	}
+
+	now = now.Add(10 * time.Second)
+	m.check(ctx)
+	m.check(ctx)
+	reqs := sender.requests()
+	if len(reqs) != 1 {
+		t.Fatalf("expected 1 missed alert, got %d", len(reqs))
+	}
+	if reqs[0].Audience != "oncall" || reqs[0].Channel != SMS {
+		t.Errorf("alert sent to %q/%q, want oncall/sms", reqs[0].Audience, reqs[0].Channel)
+	}
+	if !strings.Contains(reqs[0].Alert, "missed") {
+		t.Errorf("expected missed alert, got %q", reqs[0].Alert)
+	}
+
+	if err := m.Beat("backup"); err != nil {
+		t.Fatalf("Beat() unexpected error: %v", err)
+	}
+	m.check(ctx)
+	m.check(ctx)
+	reqs = sender.requests()
+	if len(reqs) != 2 {
+		t.Fatalf("expected 1 recovery alert, got %d alerts", len(reqs)-1)
+	}
+	if !strings.Contains(reqs[1].Alert, "recovered") {
+		t.Errorf("expected recovery alert, got %q", reqs[1].Alert)
+	}
+}
+
+func TestHeartbeatMonitorRetriesFailedAlert(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sender := &recordingSender{err: errors.New("boom")}
+	var failures int
+	m := NewHeartbeatMonitor(sender,
+		WithHeartbeatAudience("oncall"),
+		WithHeartbeatErrorHandler(func(name string, err error) { failures++ }),
+	)
+	m.now = func() time.Time { return now }
+	m.Register("job", HeartbeatConfig{Interval: time.Minute})
+
+	now = now.Add(2 * time.Minute)
+	m.check(context.Background())
+	if failures != 1 {
+		t.Fatalf("expected error handler to be called once, got %d", failures)
+	}
+
+	sender.err = nil
+	m.check(context.Background())
+	if got := len(sender.requests()); got != 1 {
+		t.Errorf("expected failed alert to be retried, got %d alerts", got)
+	}
+}
+
+func TestHeartbeatMonitorBeatDuringAlert(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var m *HeartbeatMonitor
+	sender := &recordingSender{}
+	beatWhileSending := AlertSenderFunc(func(ctx context.Context, req AlertRequest) (*AlertResponse, error) {
+		if strings.Contains(req.Alert, "missed") {
+			m.Beat("job")
+		}
+		return sender.SendAlert(ctx, req)
+	})
+	m = NewHeartbeatMonitor(beatWhileSending, WithHeartbeatAudience("oncall"))
+	m.now = func() time.Time { return now }
+	m.Register("job", HeartbeatConfig{Interval: time.Minute})
+
+	now = now.Add(2 * time.Minute)
+	m.check(context.Background())
+	m.check(context.Background())
+	reqs := sender.requests()
+	if len(reqs) != 2 || !strings.Contains(reqs[1].Alert, "recovered") {
+		t.Fatalf("expected a missed and a recovery alert, got %d alerts", len(reqs))
+	}
+}
+
+func TestHeartbeatMonitorResetsAfterFailedAlert(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sender := &recordingSender{err: errors.New("boom")}
+	m := NewHeartbeatMonitor(sender, WithHeartbeatAudience("oncall"))
+	m.now = func() time.Time { return now }
+	m.Register("job", HeartbeatConfig{Interval: time.Minute})
+
+	// The missed alert fails, then the job beats again: no outage was reported.
+	now = now.Add(2 * time.Minute)
+	m.check(context.Background())
+	m.Beat("job")
+	sender.err = nil
+	m.check(context.Background())
+	if got := len(sender.requests()); got != 0 {
+		t.Fatalf("expected no alerts, got %d", got)
+	}
+
+	// The next outage reports its own start, not the first one's.
+	now = now.Add(90 * time.Second)
+	m.check(context.Background())
+	m.Beat("job")
+	m.check(context.Background())
+	reqs := sender.requests()
+	if len(reqs) != 2 || !strings.Contains(reqs[1].Alert, "recovered after 0s") {
+		t.Errorf("expected recovery measured from the second outage, got %+v", reqs)
+	}
+}
+
+func TestHeartbeatMonitorServeHTTP(t *testing.T) {
+	m := NewHeartbeatMonitor(&recordingSender{}, WithHeartbeatAudience("oncall"))
+	m.Register("nightly", HeartbeatConfig{Interval: time.Hour})
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		wantStatus int
+	}{
+		{name: "path segment", method: http.MethodGet, target: "/heartbeat/nightly", wantStatus: http.StatusOK},
+		{name: "query parameter", method: http.MethodPost, target: "/heartbeat?name=nightly", wantStatus: http.StatusOK},
+		{name: "unknown heartbeat", method: http.MethodGet, target: "/heartbeat/weekly", wantStatus: http.StatusNotFound},
+		{name: "bad method", method: http.MethodDelete, target: "/heartbeat/nightly", wantStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			m.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestHeartbeatMonitorNonPositiveCheckInterval(t *testing.T) {
+	m := NewHeartbeatMonitor(&recordingSender{}, WithHeartbeatAudience("oncall"), WithHeartbeatCheckInterval(0))
+	if m.checkInterval != DefaultHeartbeatCheckInterval {
+		t.Errorf("checkInterval = %v, want %v", m.checkInterval, DefaultHeartbeatCheckInterval)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := m.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run() = %v, want deadline exceeded", err)
+	}
+}
@@ -0,0 +1,111 @@
+package notifox
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// WithHedging makes SendAlert hedge slow attempts: if an attempt hasn't answered
+// within delay (e.g. the observed p95 latency), a second attempt is sent on a new
+// connection with the same idempotency key. The first success is returned and the
+// other attempt is canceled. Zero disables hedging.
+//
+// A new connection needs a transport that can be cloned. If the client was given
+// an http.Client whose transport is not an *http.Transport (see WithHTTPClient),
+// the second attempt goes through that transport as is and may reuse a pooled
+// connection; a warning is logged if WithLogger is set.
+func WithHedging(delay time.Duration) ClientOption {
+	return func(c *Client) {
+		c.hedgeDelay = delay
+	}
+}
+
+// hedgeResult is the outcome of one of the attempts raced by sendHedged.
+type hedgeResult struct {
+	resp *AlertResponse
+	err  error
+}
+
+// sendHedged posts an alert, hedging the request if hedging is enabled. It also
+// returns the number of requests made.
+func (c *Client) sendHedged(ctx context.Context, path string, req AlertRequest) (*AlertResponse, int, error) {
+	if c.hedgeDelay <= 0 {
+		var resp AlertResponse
+		if err := c.doRequest(ctx, http.MethodPost, path, req, &resp); err != nil {
+			return nil, 1, err
+		}
+		return &resp, 1, nil
+	}
+
+	// Canceling ctx on return stops the losing attempt.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan hedgeResult, 2)
+	start := func(hedged bool) {
+		ctx := ctx
+		if hedged {
+			opts := requestOptionsFrom(ctx)
+			opts.freshConn = true
+			ctx = withRequestOptions(ctx, opts)
+		}
+		go func() {
+			var resp AlertResponse
+			err := c.doRequest(ctx, http.MethodPost, path, req, &resp)
+			resp.Hedged = hedged
+			results <- hedgeResult{resp: &resp, err: err}
+		}()
+	}
+
+	start(false)
+	started, pending := 1, 1
+	timer := time.NewTimer(c.hedgeDelay)
+	defer timer.Stop()
+
+	var lastErr error
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			start(true)
+			started++
+			pending++
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				return r.resp, started, nil
+			}
+			// The other attempt would fail the same way.
+			if !IsRetryable(r.err) {
+				return nil, started, r.err
+			}
+			lastErr = r.err
+		}
+	}
+
+	// Both attempts failed, or the first failed before the hedge was sent.
+	return nil, started, lastErr
+}
+
+// freshHTTPClient returns a client that opens a new connection for every request,
+// so a hedged attempt doesn't queue behind a slow pooled connection. Without an
+// *http.Transport to clone it returns the client's own HTTP client.
+func (c *Client) freshHTTPClient(ctx context.Context) *http.Client {
+	c.hedgeOnce.Do(func() {
+		t := c.Transport()
+		if t == nil {
+			c.hedgeClient = c.httpClient
+			if c.logger != nil {
+				c.logger.LogAttrs(ctx, slog.LevelWarn, "notifox hedging without a new connection: transport is not an *http.Transport")
+			}
+			return
+		}
+		fresh := t.Clone()
+		fresh.DisableKeepAlives = true
+		httpClient := *c.httpClient
+		httpClient.Transport = fresh
+		c.hedgeClient = &httpClient
+	})
+	return c.hedgeClient
+}
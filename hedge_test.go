@@ -0,0 +1,130 @@
+package notifox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHedgedSendAlert(t *testing.T) {
+	var mu sync.Mutex
+	var keys, addrs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		mu.Lock()
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+		addrs = append(addrs, r.RemoteAddr)
+		first := len(keys) == 1
+		mu.Unlock()
+
+		if first {
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		json.NewEncoder(w).Encode(AlertResponse{MessageID: "msg-1"})
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL), WithHedging(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	start := time.Now()
+	resp, err := client.SendAlert(context.Background(), AlertRequest{Audience: "oncall", Alert: "critical", IdempotencyKey: "page-42"})
+	if err != nil {
+		t.Fatalf("SendAlert() unexpected error: %v", err)
+	}
+	if !resp.Hedged || resp.Attempts != 2 {
+		t.Errorf("expected the hedged attempt to win after 2 attempts, got %+v", resp)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("hedged send took %v", elapsed)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(keys) != 2 || keys[0] != "page-42" || keys[1] != "page-42" {
+		t.Errorf("expected both attempts to carry the idempotency key, got %v", keys)
+	}
+	if addrs[0] == addrs[1] {
+		t.Error("expected the hedged attempt to use a new connection")
+	}
+}
+
+func TestHedgingNotNeeded(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		json.NewEncoder(w).Encode(AlertResponse{MessageID: "msg-1"})
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL), WithHedging(time.Second))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	resp, err := client.SendAlert(context.Background(), AlertRequest{Audience: "oncall", Alert: "fast"})
+	if err != nil {
+		t.Fatalf("SendAlert() unexpected error: %v", err)
+	}
+	if resp.Hedged || requests != 1 {
+		t.Errorf("expected a single unhedged attempt, got %d requests", requests)
+	}
+}
+
+func TestIdempotencyKeyStableAcrossRetries(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+		if len(keys) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		json.NewEncoder(w).Encode(AlertResponse{MessageID: "msg-1"})
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	if _, err := client.SendAlert(context.Background(), AlertRequest{Audience: "oncall", Alert: "retry"}); err != nil {
+		t.Fatalf("SendAlert() unexpected error: %v", err)
+	}
+	if len(keys) != 2 || keys[0] == "" || keys[0] != keys[1] {
+		t.Errorf("expected the same generated key on both attempts, got %v", keys)
+	}
+}
+
+func TestHedgingCustomTransport(t *testing.T) {
+	var logs bytes.Buffer
+	// A wrapping RoundTripper, as added by instrumentation libraries.
+	type wrappedTransport struct{ http.RoundTripper }
+	rt := wrappedTransport{http.DefaultTransport}
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithHTTPClient(&http.Client{Transport: rt}),
+		WithHedging(time.Millisecond), WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	if got := client.freshHTTPClient(context.Background()); got != client.httpClient {
+		t.Error("expected the client's own HTTP client without an *http.Transport")
+	}
+	if !strings.Contains(logs.String(), "hedging without a new connection") {
+		t.Errorf("expected a warning, got %q", logs.String())
+	}
+}
@@ -0,0 +1,256 @@
+package notifox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// DefaultJobMaxParts is the default SMS part budget for job alerts.
+	DefaultJobMaxParts = 2
+	// DefaultJobTailLines is the default number of output lines included in job alerts.
+	DefaultJobTailLines = 20
+	// jobOutputBuffer caps how much command output is kept in memory.
+	jobOutputBuffer = 64 * 1024
+	// jobAlertTimeout bounds sending the alert once the job has finished.
+	jobAlertTimeout = 30 * time.Second
+)
+
+// Job describes a command run by RunJob.
+type Job struct {
+	// Name identifies the job in alerts. Defaults to the command name.
+	Name string
+	// Command is the program to run; Args are its arguments.
+	Command string
+	Args    []string
+	// Dir and Env are passed to exec.Cmd.
+	Dir string
+	Env []string
+	// Timeout kills the command if it runs longer. Zero means no timeout.
+	Timeout time.Duration
+
+	// Audience and Channel receive the alerts.
+	Audience string
+	Channel  Channel
+	// NotifySuccess sends an alert when the command exits successfully.
+	NotifySuccess bool
+	// MaxParts is the SMS part budget for the alert; output is trimmed to fit.
+	// Zero means DefaultJobMaxParts; negative means no limit.
+	MaxParts int
+	// TailLines is how many trailing output lines to include. Zero means DefaultJobTailLines.
+	TailLines int
+	// HeartbeatURL, if set, is requested with GET after every run, whatever its
+	// outcome, so that a missing run is noticed: point it at a HeartbeatMonitor
+	// (see HeartbeatMonitor.ServeHTTP) or another dead man's switch.
+	HeartbeatURL string
+
+	// Stdout and Stderr, if set, receive the command's output as it runs.
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// JobResult describes a finished job.
+type JobResult struct {
+	ExitCode int
+	// Signal describes the signal that killed the command, if any, e.g.
+	// "terminated" or "killed"; ExitCode is then -1.
+	Signal   string
+	Duration time.Duration
+	TimedOut bool
+	// Output is the tail of the command's combined stdout and stderr.
+	Output string
+	// Err is set when the command could not be started or did not exit cleanly.
+	Err error
+	// Alert is the response for the alert sent, if any.
+	Alert *AlertResponse
+}
+
+// Success reports whether the command ran and exited with status 0.
+func (r *JobResult) Success() bool {
+	return r.Err == nil && r.ExitCode == 0 && !r.TimedOut
+}
+
+// RunJob runs a command and sends an alert through sender if it exits non-zero,
+// fails to start or times out, and optionally when it succeeds. The returned
+// error is only set if sending the alert or pinging Job.HeartbeatURL failed;
+// command failures are reported in the JobResult.
+func RunJob(ctx context.Context, sender AlertSender, job Job) (*JobResult, error) {
+	if job.Command == "" {
+		return nil, fmt.Errorf("command cannot be empty")
+	}
+	if job.Audience == "" {
+		return nil, fmt.Errorf("audience cannot be empty")
+	}
+	if job.Name == "" {
+		job.Name = job.Command
+	}
+
+	runCtx := ctx
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
+	}
+
+	output := &tailBuffer{max: jobOutputBuffer}
+	cmd := exec.CommandContext(runCtx, job.Command, job.Args...)
+	cmd.Dir = job.Dir
+	cmd.Env = job.Env
+	cmd.Stdout = teeWriter(output, job.Stdout)
+	cmd.Stderr = teeWriter(output, job.Stderr)
+	// Don't wait forever on output pipes held open by orphaned children.
+	cmd.WaitDelay = 5 * time.Second
+
+	start := time.Now()
+	err := cmd.Run()
+	result := &JobResult{
+		Duration: time.Since(start),
+		Output:   output.tail(job.tailLines()),
+		ExitCode: -1,
+	}
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	if job.Timeout > 0 && errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+		result.TimedOut = true
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		result.Err = err
+	}
+	if exitErr != nil {
+		if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+			result.Signal = ws.Signal().String()
+		}
+	}
+
+	// Alert and ping even if ctx was canceled, e.g. by the signal that killed the job.
+	alertCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), jobAlertTimeout)
+	defer cancel()
+
+	var pingErr error
+	if job.HeartbeatURL != "" {
+		pingErr = pingHeartbeat(alertCtx, job.HeartbeatURL)
+	}
+
+	if result.Success() && !job.NotifySuccess {
+		return result, pingErr
+	}
+
+	resp, err := sender.SendAlert(alertCtx, AlertRequest{
+		Audience: job.Audience,
+		Channel:  job.Channel,
+		Alert:    job.message(result),
+	})
+	if err != nil {
+		return result, errors.Join(fmt.Errorf("failed to send alert: %w", err), pingErr)
+	}
+	result.Alert = resp
+
+	return result, pingErr
+}
+
+// pingHeartbeat requests a heartbeat URL and checks that it succeeded.
+func pingHeartbeat(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to ping heartbeat: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to ping heartbeat: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to ping heartbeat: %s", resp.Status)
+	}
+	return nil
+}
+
+func (j Job) tailLines() int {
+	if j.TailLines <= 0 {
+		return DefaultJobTailLines
+	}
+	return j.TailLines
+}
+
+// message builds the alert text, trimming it to the SMS part budget.
+func (j Job) message(r *JobResult) string {
+	host, _ := os.Hostname()
+	if host != "" {
+		host = " on " + host
+	}
+	took := r.Duration.Round(time.Millisecond)
+
+	var head string
+	switch {
+	case r.TimedOut:
+		head = fmt.Sprintf("Job %q timed out after %s%s", j.Name, j.Timeout, host)
+	case r.Err != nil:
+		head = fmt.Sprintf("Job %q failed to run%s: %v", j.Name, host, r.Err)
+	case r.Signal != "":
+		head = fmt.Sprintf("Job %q killed (%s) after %s%s", j.Name, r.Signal, took, host)
+	case r.ExitCode != 0:
+		head = fmt.Sprintf("Job %q failed (exit %d) after %s%s", j.Name, r.ExitCode, took, host)
+	default:
+		head = fmt.Sprintf("Job %q succeeded in %s%s", j.Name, took, host)
+	}
+
+	maxParts := j.MaxParts
+	if maxParts == 0 {
+		maxParts = DefaultJobMaxParts
+	}
+	if r.Success() || r.Output == "" {
+		return fitTail(head, "", maxParts)
+	}
+	return fitTail(head+"\n", r.Output, maxParts)
+}
+
+// tailBuffer is an io.Writer that keeps only the last max bytes written.
+type tailBuffer struct {
+	mu  sync.Mutex
+	max int
+	buf []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.max {
+		b.buf = append(b.buf[:0], b.buf[len(b.buf)-b.max:]...)
+	}
+	return len(p), nil
+}
+
+// tail returns the last n lines written, without trailing whitespace.
+func (b *tailBuffer) tail(n int) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := strings.TrimRight(strings.ToValidUTF8(string(b.buf), ""), " \t\r\n")
+	lines := strings.Split(s, "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func teeWriter(buf *tailBuffer, w io.Writer) io.Writer {
+	if w == nil {
+		return buf
+	}
+	return io.MultiWriter(buf, w)
+}
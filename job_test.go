@@ -0,0 +1,195 @@
+package notifox
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunJob(t *testing.T) {
+	tests := []struct {
+		name         string
+		job          Job
+		wantAlert    bool
+		wantExitCode int
+		wantTimedOut bool
+		wantInAlert  string
+	}{
+		{
+			name:         "success without notification",
+			job:          Job{Command: "sh", Args: []string{"-c", "echo ok"}},
+			wantAlert:    false,
+			wantExitCode: 0,
+		},
+		{
+			name:         "success with notification",
+			job:          Job{Name: "backup", Command: "sh", Args: []string{"-c", "echo ok"}, NotifySuccess: true},
+			wantAlert:    true,
+			wantExitCode: 0,
+			wantInAlert:  `Job "backup" succeeded`,
+		},
+		{
+			name:         "non-zero exit",
+			job:          Job{Name: "backup", Command: "sh", Args: []string{"-c", "echo disk full >&2; exit 3"}},
+			wantAlert:    true,
+			wantExitCode: 3,
+			wantInAlert:  "disk full",
+		},
+		{
+			name:         "timeout",
+			job:          Job{Command: "sleep", Args: []string{"5"}, Timeout: 50 * time.Millisecond},
+			wantAlert:    true,
+			wantExitCode: -1,
+			wantTimedOut: true,
+			wantInAlert:  "timed out",
+		},
+		{
+			name:         "killed by signal",
+			job:          Job{Name: "backup", Command: "sh", Args: []string{"-c", "kill -TERM $$"}},
+			wantAlert:    true,
+			wantExitCode: -1,
+			wantInAlert:  `Job "backup" killed (terminated)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &recordingSender{}
+			tt.job.Audience = "oncall"
+
+			result, err := RunJob(context.Background(), sender, tt.job)
+			if err != nil {
+				t.Fatalf("RunJob() unexpected error: %v", err)
+			}
+			if result.ExitCode != tt.wantExitCode {
+				t.Errorf("ExitCode = %d, want %d", result.ExitCode, tt.wantExitCode)
+			}
+			if result.TimedOut != tt.wantTimedOut {
+				t.Errorf("TimedOut = %v, want %v", result.TimedOut, tt.wantTimedOut)
+			}
+
+			reqs := sender.requests()
+			if !tt.wantAlert {
+				if len(reqs) != 0 {
+					t.Errorf("expected no alert, got %q", reqs[0].Alert)
+				}
+				return
+			}
+			if len(reqs) != 1 {
+				t.Fatalf("expected 1 alert, got %d", len(reqs))
+			}
+			if !strings.Contains(reqs[0].Alert, tt.wantInAlert) {
+				t.Errorf("alert %q does not contain %q", reqs[0].Alert, tt.wantInAlert)
+			}
+		})
+	}
+}
+
+func TestRunJobTrimsOutputToPartBudget(t *testing.T) {
+	sender := &recordingSender{}
+	job := Job{
+		Name:     "noisy",
+		Command:  "sh",
+		Args:     []string{"-c", "for i in $(seq 1 200); do echo line $i; done; echo last line; exit 1"},
+		Audience: "oncall",
+		MaxParts: 1,
+	}
+
+	if _, err := RunJob(context.Background(), sender, job); err != nil {
+		t.Fatalf("RunJob() unexpected error: %v", err)
+	}
+
+	alert := sender.requests()[0].Alert
+	if parts := smsParts(alert); parts != 1 {
+		t.Errorf("alert uses %d parts, want 1: %q", parts, alert)
+	}
+	if !strings.HasSuffix(alert, "last line") {
+		t.Errorf("expected alert to keep the end of the output, got %q", alert)
+	}
+}
+
+func TestJobMessageCutsLongHead(t *testing.T) {
+	job := Job{Name: strings.Repeat("nightly-", 40), MaxParts: 1}
+	for _, r := range []*JobResult{
+		{ExitCode: 1, Output: "disk full"},
+		{ExitCode: -1, Err: errors.New(strings.Repeat("no such file ", 30))},
+	} {
+		alert := job.message(r)
+		if parts := smsParts(alert); parts != 1 || !strings.HasSuffix(alert, "...") {
+			t.Errorf("alert uses %d parts, want 1 and a marked cut: %q", parts, alert)
+		}
+	}
+}
+
+func TestSMSParts(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{name: "empty", text: "", want: 0},
+		{name: "single GSM-7 part", text: strings.Repeat("a", 160), want: 1},
+		{name: "two GSM-7 parts", text: strings.Repeat("a", 161), want: 2},
+		{name: "extended characters count twice", text: strings.Repeat("{", 81), want: 2},
+		{name: "single UCS-2 part", text: strings.Repeat("’", 70), want: 1},
+		{name: "two UCS-2 parts", text: strings.Repeat("’", 71), want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := smsParts(tt.text); got != tt.want {
+				t.Errorf("smsParts() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunJobAlertsAfterCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var alertErr error
+	sender := AlertSenderFunc(func(ctx context.Context, req AlertRequest) (*AlertResponse, error) {
+		alertErr = ctx.Err()
+		return &AlertResponse{MessageID: "msg-1"}, nil
+	})
+
+	// Cancel while the job runs, as a SIGTERM to notifox-run would.
+	time.AfterFunc(50*time.Millisecond, cancel)
+	result, err := RunJob(ctx, sender, Job{Command: "sleep", Args: []string{"5"}, Audience: "oncall"})
+	if err != nil {
+		t.Fatalf("RunJob() unexpected error: %v", err)
+	}
+	if result.Alert == nil || alertErr != nil {
+		t.Errorf("expected the alert to be sent with a live context, got alert %v, ctx error %v", result.Alert, alertErr)
+	}
+}
+
+func TestRunJobHeartbeat(t *testing.T) {
+	var pings int
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pings++
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sender := &recordingSender{}
+	job := Job{Command: "sh", Args: []string{"-c", "exit 0"}, Audience: "oncall", HeartbeatURL: server.URL + "/heartbeat/nightly"}
+	if _, err := RunJob(context.Background(), sender, job); err != nil {
+		t.Fatalf("RunJob() unexpected error: %v", err)
+	}
+
+	// Failed runs ping too; the failure itself is alerted on.
+	job.Args = []string{"-c", "exit 1"}
+	status = http.StatusNotFound
+	_, err := RunJob(context.Background(), sender, job)
+	if err == nil || !strings.Contains(err.Error(), "heartbeat") {
+		t.Errorf("RunJob() = %v, want heartbeat ping error", err)
+	}
+	if pings != 2 || len(sender.requests()) != 1 {
+		t.Errorf("got %d pings and %d alerts, want 2 and 1", pings, len(sender.requests()))
+	}
+}
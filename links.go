@@ -0,0 +1,440 @@
+package notifox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLinkMinLength is the length below which links are not shortened.
+const DefaultLinkMinLength = 30
+
+// linkPattern matches http and https links in alert text.
+var linkPattern = regexp.MustCompile(`https?://[^\s<>"']+`)
+
+// URLShortener shortens the links in an alert before it is sent. alertID is the
+// alert's AlertRequest.IdempotencyKey, so clicks can be tied back to the alert.
+type URLShortener interface {
+	ShortenURL(ctx context.Context, rawURL, alertID string) (string, error)
+}
+
+// URLShortenerFunc adapts a function to URLShortener.
+type URLShortenerFunc func(ctx context.Context, rawURL, alertID string) (string, error)
+
+// ShortenURL calls f.
+func (f URLShortenerFunc) ShortenURL(ctx context.Context, rawURL, alertID string) (string, error) {
+	return f(ctx, rawURL, alertID)
+}
+
+// WithURLShortener shortens links of at least minLength characters (or
+// DefaultLinkMinLength if zero) in every alert before it is sent. If shortening
+// a link fails, the original link is sent and the error is logged.
+func WithURLShortener(s URLShortener, minLength int) ClientOption {
+	return func(c *Client) {
+		if minLength <= 0 {
+			minLength = DefaultLinkMinLength
+		}
+		c.urlShortener = s
+		c.linkMinLength = minLength
+	}
+}
+
+// shortenLinks replaces the links in req.Alert with shortened ones.
+func (c *Client) shortenLinks(ctx context.Context, req *AlertRequest) {
+	if c.urlShortener == nil {
+		return
+	}
+
+	req.Alert = linkPattern.ReplaceAllStringFunc(req.Alert, func(link string) string {
+		// Trailing punctuation usually ends the sentence, not the link.
+		trimmed := strings.TrimRight(link, ".,;:!?)]}'")
+		if len(trimmed) < c.linkMinLength {
+			return link
+		}
+
+		short, err := c.urlShortener.ShortenURL(ctx, trimmed, req.IdempotencyKey)
+		if err != nil {
+			if c.logger != nil {
+				c.logger.LogAttrs(ctx, slog.LevelWarn, "notifox link shortening failed",
+					slog.String("error", err.Error()), slog.String("audience", req.Audience))
+			}
+			return link
+		}
+		return short + link[len(trimmed):]
+	})
+}
+
+// ShortLink is a link created by a LinkShortener.
+type ShortLink struct {
+	ID           string     `json:"id"`
+	URL          string     `json:"url"`
+	AlertID      string     `json:"alert_id,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	Clicks       int        `json:"clicks"`
+	FirstClickAt *time.Time `json:"first_click_at,omitempty"`
+	LastClickAt  *time.Time `json:"last_click_at,omitempty"`
+}
+
+// LinkShortenerOption configures a LinkShortener.
+type LinkShortenerOption func(*LinkShortener)
+
+// WithLinkClickHandler sets a function called with the updated link on every
+// click, e.g. to treat the first click on an alert's link as an acknowledgment.
+func WithLinkClickHandler(fn func(ShortLink)) LinkShortenerOption {
+	return func(s *LinkShortener) {
+		s.onClick = fn
+	}
+}
+
+// WithLinkTTL sets how long links are kept after they are created. Expired links
+// stop redirecting and are removed from the file. Zero means DefaultLinkTTL.
+func WithLinkTTL(ttl time.Duration) LinkShortenerOption {
+	return func(s *LinkShortener) {
+		if ttl > 0 {
+			s.ttl = ttl
+		}
+	}
+}
+
+// WithLinkErrorHandler sets a function called when saving clicks fails. Unsaved
+// clicks are kept in memory and saved with the next change. By default errors
+// are logged with slog.
+func WithLinkErrorHandler(fn func(err error)) LinkShortenerOption {
+	return func(s *LinkShortener) {
+		s.onError = fn
+	}
+}
+
+// linkKey identifies a link shortened for an alert.
+type linkKey struct {
+	url, alertID string
+}
+
+// LinkShortener is a self-hosted URLShortener. It is also an http.Handler that
+// redirects its short links and records clicks, stored in a JSON file. New links
+// are saved before they are returned; clicks are saved within a second, and by
+// Close.
+//
+//	links, err := notifox.NewLinkShortener("https://go.example.com/l/", "links.json")
+//	defer links.Close()
+//	http.Handle("/l/", links)
+//	client, err := notifox.NewClientWithOptions(notifox.WithURLShortener(links, 0))
+type LinkShortener struct {
+	baseURL string
+	path    string
+	ttl     time.Duration
+	onClick func(ShortLink)
+	onError func(err error)
+	now     func() time.Time
+
+	// saveMu serialises writes to the file, so an older snapshot never
+	// replaces a newer one. It is taken before mu.
+	saveMu sync.Mutex
+
+	mu        sync.Mutex
+	links     map[string]ShortLink
+	byKey     map[linkKey]string
+	byAlert   map[string][]string
+	saveTimer *time.Timer
+}
+
+// DefaultLinkTTL is how long a LinkShortener keeps links by default.
+const DefaultLinkTTL = 30 * 24 * time.Hour
+
+// linkSaveDelay batches the clicks written to the links file.
+const linkSaveDelay = time.Second
+
+// NewLinkShortener creates a shortener whose links are baseURL followed by an ID,
+// loading existing links from the file at path if it exists.
+func NewLinkShortener(baseURL, path string, opts ...LinkShortenerOption) (*LinkShortener, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid link base URL %q", baseURL)
+	}
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+
+	s := &LinkShortener{
+		baseURL: baseURL,
+		path:    path,
+		ttl:     DefaultLinkTTL,
+		now:     time.Now,
+		links:   make(map[string]ShortLink),
+		byKey:   make(map[linkKey]string),
+		byAlert: make(map[string][]string),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("failed to load links: %w", err)
+	default:
+		var links []ShortLink
+		if err := json.Unmarshal(data, &links); err != nil {
+			return nil, fmt.Errorf("failed to load links: %w", err)
+		}
+		for _, l := range links {
+			s.add(l)
+		}
+		s.prune()
+	}
+
+	return s, nil
+}
+
+// ShortenURL returns a short link for rawURL, reusing the existing one if rawURL
+// was already shortened for the same alert.
+func (s *LinkShortener) ShortenURL(ctx context.Context, rawURL, alertID string) (string, error) {
+	s.mu.Lock()
+	if id, ok := s.byKey[linkKey{rawURL, alertID}]; ok && !s.expired(s.links[id]) {
+		s.mu.Unlock()
+		return s.baseURL + id, nil
+	}
+
+	id, err := s.newID()
+	if err != nil {
+		s.mu.Unlock()
+		return "", err
+	}
+	s.add(ShortLink{ID: id, URL: rawURL, AlertID: alertID, CreatedAt: s.now()})
+	s.mu.Unlock()
+
+	// Save before the link is sent, so it survives a restart.
+	if err := s.save(); err != nil {
+		s.mu.Lock()
+		s.remove(id)
+		s.mu.Unlock()
+		return "", err
+	}
+	return s.baseURL + id, nil
+}
+
+// Link returns the short link with the given ID.
+func (s *LinkShortener) Link(id string) (ShortLink, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.links[id]
+	if !ok || s.expired(l) {
+		return ShortLink{}, false
+	}
+	return l, true
+}
+
+// Clicked reports whether any link in the alert with alertID has been clicked.
+func (s *LinkShortener) Clicked(alertID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range s.byAlert[alertID] {
+		if s.links[id].Clicks > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Close saves any clicks not yet written to the file.
+func (s *LinkShortener) Close() error {
+	s.mu.Lock()
+	pending := s.saveTimer != nil && s.saveTimer.Stop()
+	s.saveTimer = nil
+	s.mu.Unlock()
+
+	if !pending {
+		return nil
+	}
+	return s.save()
+}
+
+// ServeHTTP redirects the short link named by the last path segment and records
+// the click. HEAD requests and link preview bots, which fetch links in chat apps
+// before anyone taps them, are redirected without counting as clicks.
+func (s *LinkShortener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	default:
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := path.Base(r.URL.Path)
+	click := r.Method == http.MethodGet && !isPreviewBot(r.UserAgent())
+
+	s.mu.Lock()
+	l, ok := s.links[id]
+	ok = ok && !s.expired(l)
+	if ok && click {
+		now := s.now()
+		l.Clicks++
+		if l.FirstClickAt == nil {
+			l.FirstClickAt = &now
+		}
+		l.LastClickAt = &now
+		s.links[id] = l
+		if s.saveTimer == nil {
+			s.saveTimer = time.AfterFunc(linkSaveDelay, s.saveClicks)
+		}
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if click && s.onClick != nil {
+		s.onClick(l)
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, l.URL, http.StatusFound)
+}
+
+// saveClicks saves the clicks recorded since the last save.
+func (s *LinkShortener) saveClicks() {
+	s.mu.Lock()
+	s.saveTimer = nil
+	s.mu.Unlock()
+
+	if err := s.save(); err != nil {
+		if s.onError != nil {
+			s.onError(err)
+		} else {
+			slog.Error("notifox link shortener failed to save clicks", slog.String("error", err.Error()))
+		}
+	}
+}
+
+// isPreviewBot reports whether userAgent belongs to a link preview fetcher.
+func isPreviewBot(userAgent string) bool {
+	ua := strings.ToLower(userAgent)
+	for _, s := range []string{"bot", "facebookexternalhit", "whatsapp", "preview"} {
+		if strings.Contains(ua, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// newID returns an unused 8-character link ID. s.mu must be held.
+func (s *LinkShortener) newID() (string, error) {
+	const alphabet = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	for {
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			return "", fmt.Errorf("failed to generate link id: %w", err)
+		}
+		for i := range b {
+			b[i] = alphabet[int(b[i])%len(alphabet)]
+		}
+		if _, ok := s.links[string(b)]; !ok {
+			return string(b), nil
+		}
+	}
+}
+
+// add stores l and indexes it. s.mu must be held.
+func (s *LinkShortener) add(l ShortLink) {
+	s.links[l.ID] = l
+	s.byKey[linkKey{l.URL, l.AlertID}] = l.ID
+	if l.AlertID != "" {
+		s.byAlert[l.AlertID] = append(s.byAlert[l.AlertID], l.ID)
+	}
+}
+
+// remove deletes the link with id and its index entries. s.mu must be held.
+func (s *LinkShortener) remove(id string) {
+	l, ok := s.links[id]
+	if !ok {
+		return
+	}
+	delete(s.links, id)
+	if s.byKey[linkKey{l.URL, l.AlertID}] == id {
+		delete(s.byKey, linkKey{l.URL, l.AlertID})
+	}
+	ids := s.byAlert[l.AlertID]
+	for i, other := range ids {
+		if other == id {
+			ids = append(ids[:i], ids[i+1:]...)
+			break
+		}
+	}
+	if len(ids) == 0 {
+		delete(s.byAlert, l.AlertID)
+	} else {
+		s.byAlert[l.AlertID] = ids
+	}
+}
+
+// expired reports whether l is past the TTL. s.mu must be held.
+func (s *LinkShortener) expired(l ShortLink) bool {
+	return s.now().Sub(l.CreatedAt) > s.ttl
+}
+
+// prune removes expired links. s.mu must be held.
+func (s *LinkShortener) prune() {
+	for id, l := range s.links {
+		if s.expired(l) {
+			s.remove(id)
+		}
+	}
+}
+
+// save prunes expired links and writes the rest to the file atomically. The file
+// is written without holding s.mu, so redirects aren't held up by the disk.
+func (s *LinkShortener) save() error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	s.mu.Lock()
+	s.prune()
+	links := make([]ShortLink, 0, len(s.links))
+	for _, l := range s.links {
+		links = append(links, l)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(links, func(i, j int) bool { return links[i].CreatedAt.Before(links[j].CreatedAt) })
+	data, err := json.MarshalIndent(links, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to save links: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save links: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save links: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save links: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to save links: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package notifox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSendAlertURLShortener(t *testing.T) {
+	var sent []AlertRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req AlertRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		sent = append(sent, req)
+		json.NewEncoder(w).Encode(AlertResponse{MessageID: "msg-1"})
+	}))
+	defer server.Close()
+
+	var alertIDs []string
+	shortener := URLShortenerFunc(func(ctx context.Context, rawURL, alertID string) (string, error) {
+		if strings.Contains(rawURL, "broken") {
+			return "", errors.New("shortener down")
+		}
+		alertIDs = append(alertIDs, alertID)
+		return "https://s.co/1", nil
+	})
+
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL), WithURLShortener(shortener, 0))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	alert := "Latency high (https://grafana.example.com/d/abc123?from=now-1h). Docs: https://x.io/a, see https://grafana.example.com/broken/dashboard"
+	_, err = client.SendAlert(context.Background(), AlertRequest{Audience: "oncall", Alert: alert, IdempotencyKey: "alert-1"})
+	if err != nil {
+		t.Fatalf("SendAlert() unexpected error: %v", err)
+	}
+
+	want := "Latency high (https://s.co/1). Docs: https://x.io/a, see https://grafana.example.com/broken/dashboard"
+	if sent[0].Alert != want {
+		t.Errorf("sent %q, want %q", sent[0].Alert, want)
+	}
+	if len(alertIDs) != 1 || alertIDs[0] != "alert-1" {
+		t.Errorf("shortener called with alert IDs %v", alertIDs)
+	}
+
+	resp, err := client.SendAlert(context.Background(), AlertRequest{Audience: "oncall", Alert: alert})
+	if err != nil {
+		t.Fatalf("SendAlert() unexpected error: %v", err)
+	}
+	if resp.IdempotencyKey == "" || alertIDs[1] != resp.IdempotencyKey {
+		t.Errorf("expected the generated key %q to be returned and used for links, got %v", resp.IdempotencyKey, alertIDs)
+	}
+
+	_, err = client.SendAlert(context.Background(), AlertRequest{Audience: "oncall", Alert: alert, Delay: -time.Second})
+	if err == nil {
+		t.Fatal("SendAlert() expected error for negative delay, got nil")
+	}
+	if len(alertIDs) != 2 {
+		t.Errorf("links were shortened for a rejected alert: %v", alertIDs)
+	}
+}
+
+func TestLinkShortenerTTL(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "links.json")
+	links, err := NewLinkShortener("https://go.example.com/l/", path, WithLinkTTL(time.Hour))
+	if err != nil {
+		t.Fatalf("NewLinkShortener() unexpected error: %v", err)
+	}
+	links.now = func() time.Time { return now }
+
+	old, _ := links.ShortenURL(context.Background(), "https://grafana.example.com/d/old", "alert-1")
+	now = now.Add(2 * time.Hour)
+	if _, ok := links.Link(old[strings.LastIndex(old, "/")+1:]); ok {
+		t.Error("expected the expired link to be gone")
+	}
+
+	// Saving a new link drops the expired one from the file.
+	links.ShortenURL(context.Background(), "https://grafana.example.com/d/new", "alert-2")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "/d/old") || !strings.Contains(string(data), "/d/new") {
+		t.Errorf("unexpected links file %s", data)
+	}
+	if links.Clicked("alert-1") {
+		t.Error("expired alert should not report clicks")
+	}
+}
+
+func TestLinkShortener(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "links.json")
+
+	var clicks []ShortLink
+	links, err := NewLinkShortener("https://go.example.com/l", path, WithLinkClickHandler(func(l ShortLink) {
+		clicks = append(clicks, l)
+	}))
+	if err != nil {
+		t.Fatalf("NewLinkShortener() unexpected error: %v", err)
+	}
+
+	short, err := links.ShortenURL(context.Background(), "https://grafana.example.com/d/abc123", "alert-1")
+	if err != nil {
+		t.Fatalf("ShortenURL() unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(short, "https://go.example.com/l/") {
+		t.Fatalf("ShortenURL() = %q", short)
+	}
+	if again, _ := links.ShortenURL(context.Background(), "https://grafana.example.com/d/abc123", "alert-1"); again != short {
+		t.Errorf("same link and alert shortened twice: %q, %q", short, again)
+	}
+	if other, _ := links.ShortenURL(context.Background(), "https://grafana.example.com/d/abc123", "alert-2"); other == short {
+		t.Error("different alerts should get different links")
+	}
+
+	id := short[strings.LastIndex(short, "/")+1:]
+	request := func(method, userAgent string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/l/"+id, nil)
+		req.Header.Set("User-Agent", userAgent)
+		rec := httptest.NewRecorder()
+		links.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := request(http.MethodGet, "facebookexternalhit/1.1 Facebot Twitterbot/1.0"); rec.Code != http.StatusFound {
+		t.Errorf("preview status = %d, want %d", rec.Code, http.StatusFound)
+	}
+	if links.Clicked("alert-1") {
+		t.Error("link preview should not count as a click")
+	}
+
+	rec := request(http.MethodGet, "Mozilla/5.0 (iPhone)")
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "https://grafana.example.com/d/abc123" {
+		t.Errorf("redirect = %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	if !links.Clicked("alert-1") || links.Clicked("alert-2") {
+		t.Error("expected only alert-1 to be clicked")
+	}
+	if len(clicks) != 1 || clicks[0].AlertID != "alert-1" || clicks[0].Clicks != 1 {
+		t.Errorf("click handler got %+v", clicks)
+	}
+
+	if rec := request(http.MethodPost, ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+	id = "missing"
+	if rec := request(http.MethodGet, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown link status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	if err := links.Close(); err != nil {
+		t.Fatalf("Close() unexpected error: %v", err)
+	}
+	reloaded, err := NewLinkShortener("https://go.example.com/l/", path)
+	if err != nil {
+		t.Fatalf("NewLinkShortener() unexpected error: %v", err)
+	}
+	l, ok := reloaded.Link(short[strings.LastIndex(short, "/")+1:])
+	if !ok || l.Clicks != 1 || l.FirstClickAt == nil {
+		t.Errorf("reloaded link = %+v, %v", l, ok)
+	}
+
+	if _, err := NewLinkShortener("go.example.com", path); err == nil {
+		t.Error("NewLinkShortener() expected error for base URL without scheme, got nil")
+	}
+}
@@ -0,0 +1,201 @@
+package notifox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// redacted replaces sensitive values in logs.
+const redacted = "[REDACTED]"
+
+// DefaultLogRedactFields are the JSON fields whose values are never logged.
+// Verification codes ("code") are not included, since error responses use the
+// same field; add it with WithLogRedaction to keep them out of logs.
+var DefaultLogRedactFields = []string{"api_key", "secret", "token"}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	// phonePattern matches international (+44 20 7946 0958) and North American
+	// ((555) 123-4567) numbers, but not dates or IDs.
+	phonePattern = regexp.MustCompile(`\+\d[\d ().-]{6,}\d|\(?\b\d{3}\)?[ .-]\d{3}[ .-]\d{4}\b`)
+)
+
+// WithLogger logs each request attempt to logger: method, path, status, latency
+// and error at Info (failures at Warn), plus retry decisions. When the logger is
+// enabled for Debug, request and response headers and bodies are logged too, with
+// the Authorization header, phone numbers, email addresses and the fields set by
+// WithLogRedaction redacted.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithLogRedaction sets the JSON fields whose values are redacted from logged
+// bodies, replacing DefaultLogRedactFields. Include "alert" to keep alert text out
+// of logs.
+func WithLogRedaction(fields ...string) ClientOption {
+	return func(c *Client) {
+		c.redactFields = make(map[string]bool, len(fields))
+		for _, f := range fields {
+			c.redactFields[f] = true
+		}
+	}
+}
+
+// logAttempt logs the outcome of one HTTP attempt.
+func (c *Client) logAttempt(ctx context.Context, method, url string, status int, latency time.Duration, err error) {
+	if c.logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("path", urlPath(url)),
+		slog.Duration("latency", latency),
+	}
+	if status != 0 {
+		attrs = append(attrs, slog.Int("status", status))
+	}
+	if key := requestOptionsFrom(ctx).idempotencyKey; key != "" {
+		attrs = append(attrs, slog.String("idempotency_key", key))
+	}
+
+	if err == nil {
+		c.logger.LogAttrs(ctx, slog.LevelInfo, "notifox request", attrs...)
+		return
+	}
+	attrs = append(attrs, slog.String("error", redactText(err.Error())))
+	var connErr *NotifoxConnectionError
+	if errors.As(err, &connErr) && connErr.Reason != "" {
+		attrs = append(attrs, slog.String("reason", string(connErr.Reason)))
+	}
+	c.logger.LogAttrs(ctx, slog.LevelWarn, "notifox request failed", attrs...)
+}
+
+// logRetry logs a retry decision after a failed attempt. A zero backoff means
+// the request is not retried.
+func (c *Client) logRetry(ctx context.Context, level slog.Level, msg string, attempt int, backoff time.Duration, err error) {
+	if c.logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.Int("attempt", attempt),
+		slog.String("error", redactText(err.Error())),
+	}
+	if backoff > 0 {
+		attrs = append(attrs, slog.Duration("backoff", backoff))
+	}
+	c.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// debugEnabled reports whether bodies should be logged.
+func (c *Client) debugEnabled(ctx context.Context) bool {
+	return c.logger != nil && c.logger.Enabled(ctx, slog.LevelDebug)
+}
+
+// logBodies logs the request and response headers and bodies at Debug.
+func (c *Client) logBodies(ctx context.Context, req *http.Request, reqBody []byte, resp *http.Response, respBody []byte) {
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.RequestURI()),
+		slog.Any("request_headers", redactHeader(req.Header)),
+		slog.String("request_body", c.redactBody(reqBody)),
+	}
+	if resp != nil {
+		attrs = append(attrs,
+			slog.Int("status", resp.StatusCode),
+			slog.Any("response_headers", redactHeader(resp.Header)),
+			slog.String("response_body", c.redactBody(respBody)),
+		)
+	}
+	c.logger.LogAttrs(ctx, slog.LevelDebug, "notifox request body", attrs...)
+}
+
+// redactHeader returns a copy of h with credentials redacted.
+func redactHeader(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		switch http.CanonicalHeaderKey(k) {
+		case "Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie":
+			out[k] = redacted
+		default:
+			out[k] = strings.Join(v, ", ")
+		}
+	}
+	return out
+}
+
+// redactBody redacts the client's redact fields from a JSON body and phone numbers
+// and email addresses from all string values. Non-JSON bodies are redacted as text.
+func (c *Client) redactBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	fields := c.redactFields
+	if fields == nil {
+		fields = make(map[string]bool, len(DefaultLogRedactFields))
+		for _, f := range DefaultLogRedactFields {
+			fields[f] = true
+		}
+	}
+
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return redactText(string(body))
+	}
+	out, err := json.Marshal(redactJSON(v, fields))
+	if err != nil {
+		return redacted
+	}
+	return string(out)
+}
+
+func redactJSON(v any, fields map[string]bool) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			if fields[k] {
+				v[k] = redacted
+			} else {
+				v[k] = redactJSON(val, fields)
+			}
+		}
+		return v
+	case []any:
+		for i, val := range v {
+			v[i] = redactJSON(val, fields)
+		}
+		return v
+	case string:
+		return redactText(v)
+	}
+	return v
+}
+
+// redactText replaces phone numbers and email addresses in s.
+func redactText(s string) string {
+	s = emailPattern.ReplaceAllString(s, redacted)
+	return phonePattern.ReplaceAllString(s, redacted)
+}
+
+// urlPath returns the path of a URL, without the host or query.
+func urlPath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	if u.Path == "" {
+		return "/"
+	}
+	return u.Path
+}
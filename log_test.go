@@ -0,0 +1,105 @@
+package notifox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithLogger(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`{"error": "upstream down"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(AlertResponse{MessageID: "msg-1"})
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL), WithLogger(logger))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	alert := "Customer jane.doe@example.com (+1 415 555 0100) cannot log in"
+	if _, err := client.SendAlert(context.Background(), AlertRequest{Audience: "oncall", Alert: alert}); err != nil {
+		t.Fatalf("SendAlert() unexpected error: %v", err)
+	}
+
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		records = append(records, rec)
+	}
+
+	var msgs []string
+	for _, rec := range records {
+		msgs = append(msgs, rec["msg"].(string))
+	}
+	want := []string{"notifox request body", "notifox request failed", "notifox retrying", "notifox request body", "notifox request"}
+	if strings.Join(msgs, ",") != strings.Join(want, ",") {
+		t.Errorf("log messages = %v, want %v", msgs, want)
+	}
+
+	failed := records[1]
+	if failed["status"] != float64(502) || failed["path"] != "/alert" || failed["latency"] == nil || failed["error"] == nil {
+		t.Errorf("unexpected failed attempt record %v", failed)
+	}
+
+	out := buf.String()
+	for _, secret := range []string{"test-api-key", "jane.doe@example.com", "555 0100"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("log output contains %q", secret)
+		}
+	}
+	if !strings.Contains(out, "cannot log in") {
+		t.Error("expected the rest of the alert text in debug logs")
+	}
+}
+
+func TestLogRedaction(t *testing.T) {
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithLogRedaction("alert"))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	got := client.redactBody([]byte(`{"audience":"oncall","alert":"secret text","meta":{"alert":"nested"},"when":"2024-01-01"}`))
+	if strings.Contains(got, "secret text") || strings.Contains(got, "nested") {
+		t.Errorf("alert field not redacted: %s", got)
+	}
+	if !strings.Contains(got, `"audience":"oncall"`) || !strings.Contains(got, "2024-01-01") {
+		t.Errorf("other fields should be kept: %s", got)
+	}
+
+	if got := redactText("call (555) 123-4567 or write to ops@example.org"); got != "call [REDACTED] or write to [REDACTED]" {
+		t.Errorf("redactText() = %q", got)
+	}
+	defaults, _ := NewClientWithOptions(WithAPIKey("test-api-key"))
+	got = defaults.redactBody([]byte(`{"error":"audience not found","code":"audience_not_found","token":"t0k3n"}`))
+	if !strings.Contains(got, "audience_not_found") || strings.Contains(got, "t0k3n") {
+		t.Errorf("expected error code kept and token redacted: %s", got)
+	}
+
+	for raw, want := range map[string]string{
+		"https://api.notifox.com/alert":             "/alert",
+		"https://api.notifox.com":                   "/",
+		"https://api.notifox.com/messages/1?wait=1": "/messages/1",
+	} {
+		if got := urlPath(raw); got != want {
+			t.Errorf("urlPath(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
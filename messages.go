@@ -0,0 +1,172 @@
+package notifox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	// DefaultPollInterval is the default initial interval between delivery status polls.
+	DefaultPollInterval = time.Second
+	// DefaultMaxPollInterval is the default upper bound on the interval between polls.
+	DefaultMaxPollInterval = 10 * time.Second
+)
+
+// ErrDeliveryTimeout is returned by WaitForDelivery when the message has not reached
+// a terminal state within WaitOptions.Timeout.
+var ErrDeliveryTimeout = errors.New("notifox: message not delivered before timeout")
+
+// errWaitTimeout is the cause of WaitForDelivery's own deadline.
+var errWaitTimeout = errors.New("wait timeout")
+
+// MessageStatus is the delivery status of a message or recipient.
+type MessageStatus string
+
+const (
+	// MessageQueued means the message was accepted but not yet handed to a carrier.
+	MessageQueued MessageStatus = "queued"
+	// MessageSent means the message was handed to a carrier.
+	MessageSent MessageStatus = "sent"
+	// MessageDelivered means the carrier confirmed delivery.
+	MessageDelivered MessageStatus = "delivered"
+	// MessageFailed means the message could not be delivered.
+	MessageFailed MessageStatus = "failed"
+)
+
+// Terminal reports whether the status is final.
+func (s MessageStatus) Terminal() bool {
+	return s == MessageDelivered || s == MessageFailed
+}
+
+// Message represents a sent alert and its delivery status.
+type Message struct {
+	MessageID   string            `json:"message_id"`
+	Status      MessageStatus     `json:"status"`
+	Audience    string            `json:"audience"`
+	Channel     Channel           `json:"channel"`
+	Parts       int               `json:"parts"`
+	Cost        float64           `json:"cost"`
+	Currency    string            `json:"currency"`
+	CreatedAt   time.Time         `json:"created_at"`
+	SentAt      *time.Time        `json:"sent_at,omitempty"`
+	DeliveredAt *time.Time        `json:"delivered_at,omitempty"`
+	FailedAt    *time.Time        `json:"failed_at,omitempty"`
+	Recipients  []RecipientResult `json:"recipients"`
+}
+
+// RecipientResult is the delivery status of a message for a single recipient.
+type RecipientResult struct {
+	// Recipient is the phone number or email address the message was sent to.
+	Recipient string        `json:"recipient"`
+	Channel   Channel       `json:"channel"`
+	Status    MessageStatus `json:"status"`
+	Error     string        `json:"error,omitempty"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// WaitOptions configures WaitForDelivery.
+type WaitOptions struct {
+	// PollInterval is the initial interval between polls. Zero means DefaultPollInterval.
+	PollInterval time.Duration
+	// MaxPollInterval caps the interval, which doubles after every poll.
+	// Zero means DefaultMaxPollInterval.
+	MaxPollInterval time.Duration
+	// Timeout is how long to wait for a terminal status, including polls in flight.
+	// Zero means until ctx is done.
+	Timeout time.Duration
+}
+
+// GetMessage returns the delivery status of a message sent with SendAlert.
+func (c *Client) GetMessage(ctx context.Context, messageID string) (*Message, error) {
+	if messageID == "" {
+		return nil, fmt.Errorf("message ID cannot be empty")
+	}
+
+	var msg Message
+	if err := c.doWithRetry(ctx, http.MethodGet, "/messages/"+url.PathEscape(messageID), nil, &msg); err != nil {
+		return nil, err
+	}
+
+	return &msg, nil
+}
+
+// WaitForDelivery polls GetMessage with backoff until the message is delivered or
+// failed, and returns it. Check Message.Status to tell the two apart.
+//
+// If opts.Timeout passes first, it returns the last status seen (nil if none) and
+// an error matching ErrDeliveryTimeout, so critical pages can fall back to another
+// channel:
+//
+//	msg, err := client.WaitForDelivery(ctx, resp.MessageID, notifox.WaitOptions{Timeout: 30 * time.Second})
+//	if errors.Is(err, notifox.ErrDeliveryTimeout) || (err == nil && msg.Status == notifox.MessageFailed) {
+//		// Page by email instead.
+//	}
+func (c *Client) WaitForDelivery(ctx context.Context, messageID string, opts WaitOptions) (*Message, error) {
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	maxInterval := opts.MaxPollInterval
+	if maxInterval <= 0 {
+		maxInterval = DefaultMaxPollInterval
+	}
+
+	// The timeout covers in-flight polls, including their retries, and is told
+	// apart from the caller's own deadline by its cause.
+	waitCtx := ctx
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeoutCause(ctx, opts.Timeout, errWaitTimeout)
+		defer cancel()
+	}
+
+	var last *Message
+	timeout := func() error {
+		status := MessageStatus("unknown")
+		if last != nil {
+			status = last.Status
+		}
+		return fmt.Errorf("%w: message %s is %s after %s", ErrDeliveryTimeout, messageID, status, opts.Timeout)
+	}
+	timedOut := func() bool {
+		return ctx.Err() == nil && errors.Is(context.Cause(waitCtx), errWaitTimeout)
+	}
+
+	for {
+		msg, err := c.GetMessage(waitCtx, messageID)
+		switch {
+		case err == nil:
+			last = msg
+			if msg.Status.Terminal() {
+				return msg, nil
+			}
+		case timedOut():
+			return last, timeout()
+		case isNotFound(err):
+			// The message may not be visible yet right after sending.
+		case !IsTemporary(err):
+			return last, err
+		}
+
+		select {
+		case <-waitCtx.Done():
+			if timedOut() {
+				return last, timeout()
+			}
+			return last, ctx.Err()
+		case <-time.After(interval):
+		}
+
+		interval = min(interval*2, maxInterval)
+	}
+}
+
+// isNotFound reports whether err is an API error with status 404.
+func isNotFound(err error) bool {
+	var apiErr *NotifoxAPIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
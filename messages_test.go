@@ -0,0 +1,150 @@
+package notifox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGetMessage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("expected GET, got %s", r.Method)
+		}
+		if r.URL.Path != "/messages/msg-1" {
+			t.Errorf("expected /messages/msg-1, got %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-api-key" {
+			t.Errorf("expected Authorization header, got %s", r.Header.Get("Authorization"))
+		}
+
+		w.Write([]byte(`{
+			"message_id": "msg-1",
+			"status": "delivered",
+			"channel": "sms",
+			"cost": 0.05,
+			"currency": "USD",
+			"created_at": "2024-01-01T00:00:00Z",
+			"delivered_at": "2024-01-01T00:00:05Z",
+			"recipients": [{"recipient": "+15551234567", "channel": "sms", "status": "delivered"}]
+		}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	msg, err := client.GetMessage(context.Background(), "msg-1")
+	if err != nil {
+		t.Fatalf("GetMessage() unexpected error: %v", err)
+	}
+	if msg.Status != MessageDelivered || !msg.Status.Terminal() {
+		t.Errorf("Status = %q, want delivered", msg.Status)
+	}
+	if msg.DeliveredAt == nil || !msg.DeliveredAt.Equal(time.Date(2024, 1, 1, 0, 0, 5, 0, time.UTC)) {
+		t.Errorf("DeliveredAt = %v, want 2024-01-01T00:00:05Z", msg.DeliveredAt)
+	}
+	if len(msg.Recipients) != 1 || msg.Recipients[0].Recipient != "+15551234567" {
+		t.Errorf("unexpected recipients %+v", msg.Recipients)
+	}
+
+	if _, err := client.GetMessage(context.Background(), ""); err == nil {
+		t.Error("GetMessage() expected error for empty ID, got nil")
+	}
+}
+
+func TestWaitForDelivery(t *testing.T) {
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		switch polls {
+		case 1:
+			// Not visible yet right after sending.
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "message not found"})
+		case 2:
+			json.NewEncoder(w).Encode(Message{MessageID: "msg-1", Status: MessageQueued})
+		case 3:
+			json.NewEncoder(w).Encode(Message{MessageID: "msg-1", Status: MessageSent})
+		default:
+			json.NewEncoder(w).Encode(Message{MessageID: "msg-1", Status: MessageDelivered})
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	msg, err := client.WaitForDelivery(context.Background(), "msg-1", WaitOptions{PollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("WaitForDelivery() unexpected error: %v", err)
+	}
+	if msg.Status != MessageDelivered {
+		t.Errorf("Status = %q, want delivered", msg.Status)
+	}
+	if polls != 4 {
+		t.Errorf("expected 4 polls, got %d", polls)
+	}
+}
+
+func TestWaitForDeliveryTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Message{MessageID: "msg-1", Status: MessageSent})
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	msg, err := client.WaitForDelivery(context.Background(), "msg-1", WaitOptions{
+		PollInterval: time.Millisecond,
+		Timeout:      50 * time.Millisecond,
+	})
+	if !errors.Is(err, ErrDeliveryTimeout) {
+		t.Fatalf("expected ErrDeliveryTimeout, got %v", err)
+	}
+	if msg == nil || msg.Status != MessageSent {
+		t.Errorf("expected last seen status sent, got %+v", msg)
+	}
+}
+
+func TestWaitForDeliveryTimeoutDuringPoll(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	start := time.Now()
+	_, err = client.WaitForDelivery(context.Background(), "msg-1", WaitOptions{Timeout: 50 * time.Millisecond})
+	if !errors.Is(err, ErrDeliveryTimeout) {
+		t.Fatalf("expected ErrDeliveryTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("WaitForDelivery() took %s, want it bounded by the timeout", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = client.WaitForDelivery(ctx, "msg-1", WaitOptions{Timeout: time.Minute})
+	if errors.Is(err, ErrDeliveryTimeout) {
+		t.Errorf("caller's own deadline should not be reported as ErrDeliveryTimeout, got %v", err)
+	}
+}
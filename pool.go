@@ -0,0 +1,462 @@
+package notifox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPoolIdleTimeout is how long a ClientPool keeps an unused tenant's client.
+	DefaultPoolIdleTimeout = 10 * time.Minute
+	// DefaultTenantBudgetPeriod is the period a TenantConfig.Budget applies to.
+	DefaultTenantBudgetPeriod = 24 * time.Hour
+)
+
+var (
+	// ErrUnknownTenant is returned by a TenantResolver for a tenant it has no config for.
+	ErrUnknownTenant = errors.New("notifox: unknown tenant")
+	// ErrTenantRateLimited is returned when a tenant exceeds its rate limit.
+	ErrTenantRateLimited = errors.New("notifox: tenant rate limit exceeded")
+	// ErrTenantBudgetExceeded is returned when a tenant has used its alert budget for the period.
+	ErrTenantBudgetExceeded = errors.New("notifox: tenant alert budget exceeded")
+)
+
+// TenantConfig configures a tenant's client and limits.
+type TenantConfig struct {
+	// APIKey is the tenant's API key. Ignored if Credentials is set.
+	APIKey string
+	// Credentials supplies the tenant's API key, e.g. for keys that rotate.
+	Credentials CredentialProvider
+	// Options are applied to the tenant's client after the pool's client options.
+	Options []ClientOption
+
+	// RateLimit is the sustained number of alerts per second. Zero means unlimited.
+	RateLimit float64
+	// Burst is the number of alerts allowed at once. Defaults to 1 when RateLimit is set.
+	Burst int
+	// Budget is the maximum number of alerts per BudgetPeriod. Zero means unlimited.
+	Budget int
+	// BudgetPeriod defaults to DefaultTenantBudgetPeriod.
+	BudgetPeriod time.Duration
+}
+
+// TenantResolver returns the config for a tenant, e.g. by looking up its API key.
+// It is called when the tenant's client is first needed and again after it is evicted.
+type TenantResolver func(ctx context.Context, tenant string) (TenantConfig, error)
+
+// TenantStats are a tenant's counters since it was first used, or since the pool
+// last forgot it for being idle.
+type TenantStats struct {
+	Tenant string
+	// Sent and Failed count alerts accepted and rejected by the API.
+	Sent   int
+	Failed int
+	// RateLimited and BudgetExceeded count alerts refused by the pool.
+	RateLimited    int
+	BudgetExceeded int
+	// BudgetUsed is the number of alerts counted against the current budget
+	// period, if the tenant has a budget.
+	BudgetUsed int
+	LastUsed   time.Time
+	// Active reports whether the tenant currently has a client.
+	Active bool
+}
+
+// PoolOption is a function that configures a ClientPool.
+type PoolOption func(*ClientPool)
+
+// WithPoolIdleTimeout sets how long an unused tenant's client is kept.
+func WithPoolIdleTimeout(timeout time.Duration) PoolOption {
+	return func(p *ClientPool) {
+		p.idleTimeout = timeout
+	}
+}
+
+// WithPoolClientOptions sets options applied to every tenant's client. Transport
+// options such as WithProxy give each client its own transport; to keep sharing
+// one, configure it with WithPoolTransport instead.
+func WithPoolClientOptions(opts ...ClientOption) PoolOption {
+	return func(p *ClientPool) {
+		p.clientOpts = append(p.clientOpts, opts...)
+	}
+}
+
+// WithPoolTransport sets the transport shared by all clients in the pool.
+func WithPoolTransport(transport http.RoundTripper) PoolOption {
+	return func(p *ClientPool) {
+		p.transport = transport
+	}
+}
+
+// ClientPool sends alerts on behalf of many tenants, each with its own API key.
+// Clients are created on first use, share one HTTP transport and are evicted after
+// being idle. Rate limits and budgets are enforced per tenant. A tenant idle for
+// a day, or longer if its limits take longer to reset, is forgotten along with
+// its counters.
+type ClientPool struct {
+	resolve     TenantResolver
+	transport   http.RoundTripper
+	clientOpts  []ClientOption
+	idleTimeout time.Duration
+	now         func() time.Time
+
+	mu        sync.Mutex
+	tenants   map[string]*tenantState
+	lastSweep time.Time
+}
+
+// tenantState is a tenant's client, limits and counters. Limits and counters
+// outlive the client so that eviction doesn't reset them.
+type tenantState struct {
+	mu     sync.Mutex
+	client *Client
+	limit  *tokenBucket
+	// resolving is closed when an in-progress resolve finishes.
+	resolving chan struct{}
+	// removed is set when the pool forgets the tenant.
+	removed bool
+
+	budget       int
+	budgetPeriod time.Duration
+	budgetStart  time.Time
+
+	stats TenantStats
+}
+
+// NewClientPool creates a pool that looks up tenants with resolve.
+func NewClientPool(resolve TenantResolver, opts ...PoolOption) *ClientPool {
+	p := &ClientPool{
+		resolve:     resolve,
+		idleTimeout: DefaultPoolIdleTimeout,
+		now:         time.Now,
+		tenants:     make(map[string]*tenantState),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if p.transport == nil {
+		p.transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+
+	return p
+}
+
+// SendAlert sends an alert with the tenant's client, subject to its rate limit and budget.
+func (p *ClientPool) SendAlert(ctx context.Context, tenant string, req AlertRequest) (*AlertResponse, error) {
+	state, client, err := p.acquire(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	charged, err := p.reserve(tenant, state)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.SendAlert(ctx, req)
+
+	state.mu.Lock()
+	if err != nil {
+		state.stats.Failed++
+		// Failed alerts don't count against the budget, unless it has since
+		// started a new period.
+		if !charged.IsZero() && state.budgetStart.Equal(charged) && state.stats.BudgetUsed > 0 {
+			state.stats.BudgetUsed--
+		}
+	} else {
+		state.stats.Sent++
+	}
+	state.mu.Unlock()
+
+	return resp, err
+}
+
+// Client returns the tenant's client, creating it if needed. Alerts sent with it
+// directly bypass the pool's limits and counters.
+func (p *ClientPool) Client(ctx context.Context, tenant string) (*Client, error) {
+	_, client, err := p.acquire(ctx, tenant)
+	return client, err
+}
+
+// Evict drops the tenant's client, so its config is resolved again on next use.
+func (p *ClientPool) Evict(tenant string) {
+	p.mu.Lock()
+	state := p.tenants[tenant]
+	p.mu.Unlock()
+
+	if state != nil {
+		state.mu.Lock()
+		state.evict()
+		state.mu.Unlock()
+	}
+}
+
+// Stats returns the counters for a tenant.
+func (p *ClientPool) Stats(tenant string) TenantStats {
+	p.mu.Lock()
+	state := p.tenants[tenant]
+	p.mu.Unlock()
+
+	if state == nil {
+		return TenantStats{Tenant: tenant}
+	}
+	return state.snapshot()
+}
+
+// AllStats returns the counters for every known tenant, sorted by tenant.
+func (p *ClientPool) AllStats() []TenantStats {
+	p.mu.Lock()
+	states := make([]*tenantState, 0, len(p.tenants))
+	for _, s := range p.tenants {
+		states = append(states, s)
+	}
+	p.mu.Unlock()
+
+	stats := make([]TenantStats, 0, len(states))
+	for _, s := range states {
+		stats = append(stats, s.snapshot())
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Tenant < stats[j].Tenant })
+	return stats
+}
+
+// acquire returns the tenant's state and client, resolving the tenant if it has
+// no client.
+func (p *ClientPool) acquire(ctx context.Context, tenant string) (*tenantState, *Client, error) {
+	if tenant == "" {
+		return nil, nil, fmt.Errorf("tenant cannot be empty")
+	}
+
+	for {
+		now := p.now()
+
+		p.mu.Lock()
+		p.sweep(now)
+		state, ok := p.tenants[tenant]
+		if !ok {
+			state = &tenantState{stats: TenantStats{Tenant: tenant}}
+			p.tenants[tenant] = state
+		}
+		p.mu.Unlock()
+
+		state.mu.Lock()
+		if state.removed {
+			// Forgotten since the lookup; look it up again.
+			state.mu.Unlock()
+			continue
+		}
+		state.stats.LastUsed = now
+		if client := state.client; client != nil {
+			state.mu.Unlock()
+			return state, client, nil
+		}
+		if done := state.resolving; done != nil {
+			state.mu.Unlock()
+			select {
+			case <-done:
+				continue
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			}
+		}
+
+		// Resolve without holding the lock, so that stats and other callers
+		// aren't blocked on a slow resolver.
+		done := make(chan struct{})
+		state.resolving = done
+		state.mu.Unlock()
+
+		cfg, client, err := p.resolveClient(ctx, tenant)
+
+		state.mu.Lock()
+		state.resolving = nil
+		close(done)
+		if err == nil {
+			state.client = client
+			state.configure(cfg, now)
+		}
+		state.mu.Unlock()
+
+		if err != nil {
+			return nil, nil, err
+		}
+		return state, client, nil
+	}
+}
+
+// resolveClient resolves a tenant's config and creates its client.
+func (p *ClientPool) resolveClient(ctx context.Context, tenant string) (TenantConfig, *Client, error) {
+	cfg, err := p.resolve(ctx, tenant)
+	if err != nil {
+		return cfg, nil, fmt.Errorf("failed to resolve tenant %q: %w", tenant, err)
+	}
+	client, err := p.newClient(cfg)
+	if err != nil {
+		return cfg, nil, fmt.Errorf("failed to create client for tenant %q: %w", tenant, err)
+	}
+	return cfg, client, nil
+}
+
+// newClient creates a client for a tenant using the shared transport.
+func (p *ClientPool) newClient(cfg TenantConfig) (*Client, error) {
+	opts := []ClientOption{WithHTTPClient(&http.Client{Transport: p.transport, Timeout: DefaultTimeout})}
+	opts = append(opts, p.clientOpts...)
+	if cfg.Credentials != nil {
+		opts = append(opts, WithCredentialProvider(cfg.Credentials))
+	} else {
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("api key cannot be empty")
+		}
+		opts = append(opts, WithAPIKey(cfg.APIKey))
+	}
+	opts = append(opts, cfg.Options...)
+
+	return NewClientWithOptions(opts...)
+}
+
+// reserve counts an alert against the tenant's rate limit and budget. It returns
+// the start of the budget period charged, or the zero time if there is no budget.
+func (p *ClientPool) reserve(tenant string, state *tenantState) (time.Time, error) {
+	now := p.now()
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	if state.limit != nil && !state.limit.allow(now) {
+		state.stats.RateLimited++
+		return time.Time{}, fmt.Errorf("%w: tenant %q", ErrTenantRateLimited, tenant)
+	}
+
+	if state.budget > 0 {
+		if now.Sub(state.budgetStart) >= state.budgetPeriod {
+			state.budgetStart = now
+			state.stats.BudgetUsed = 0
+		}
+		if state.stats.BudgetUsed >= state.budget {
+			state.stats.BudgetExceeded++
+			return time.Time{}, fmt.Errorf("%w: tenant %q has sent %d alerts since %s",
+				ErrTenantBudgetExceeded, tenant, state.stats.BudgetUsed, state.budgetStart.Format(time.RFC3339))
+		}
+		state.stats.BudgetUsed++
+		return state.budgetStart, nil
+	}
+
+	return time.Time{}, nil
+}
+
+// sweep evicts clients idle for longer than the idle timeout, keeping tenant
+// limits and counters until they would have reset anyway (see
+// tenantState.retention). It runs at most once per half idle timeout. p.mu must
+// be held.
+func (p *ClientPool) sweep(now time.Time) {
+	if p.idleTimeout <= 0 || now.Sub(p.lastSweep) < p.idleTimeout/2 {
+		return
+	}
+	p.lastSweep = now
+
+	for tenant, state := range p.tenants {
+		// A busy tenant is in use; don't wait on it.
+		if !state.mu.TryLock() {
+			continue
+		}
+		idle := now.Sub(state.stats.LastUsed)
+		if idle >= p.idleTimeout {
+			state.evict()
+		}
+		if state.resolving == nil && idle >= state.retention(p.idleTimeout) {
+			state.removed = true
+			delete(p.tenants, tenant)
+		}
+		state.mu.Unlock()
+	}
+}
+
+// configure applies a resolved config's limits. Existing limit state is kept so
+// that re-resolving after eviction doesn't reset it. s.mu must be held.
+func (s *tenantState) configure(cfg TenantConfig, now time.Time) {
+	if cfg.RateLimit > 0 {
+		burst := max(cfg.Burst, 1)
+		if s.limit == nil {
+			s.limit = newTokenBucket(cfg.RateLimit, burst, now)
+		} else {
+			s.limit.rate, s.limit.burst = cfg.RateLimit, float64(burst)
+		}
+	} else {
+		s.limit = nil
+	}
+
+	s.budget = cfg.Budget
+	s.budgetPeriod = cfg.BudgetPeriod
+	if s.budgetPeriod <= 0 {
+		s.budgetPeriod = DefaultTenantBudgetPeriod
+	}
+	if s.budgetStart.IsZero() {
+		s.budgetStart = now
+	}
+}
+
+// evict drops the client. Only its health probe is stopped: Client.Close would
+// also close the idle connections of the transport shared by the pool. s.mu must
+// be held.
+func (s *tenantState) evict() {
+	if s.client != nil {
+		s.client.stopHealthProbe()
+		s.client = nil
+	}
+}
+
+// retention is how long the tenant must be idle before it is forgotten: a day
+// (DefaultTenantBudgetPeriod), the idle timeout, its budget period or the time
+// its rate limit takes to refill, whichever is longest. s.mu must be held.
+func (s *tenantState) retention(idleTimeout time.Duration) time.Duration {
+	d := max(idleTimeout, DefaultTenantBudgetPeriod)
+	if s.budget > 0 {
+		d = max(d, s.budgetPeriod)
+	}
+	if s.limit != nil {
+		d = max(d, time.Duration(s.limit.burst/s.limit.rate*float64(time.Second)))
+	}
+	return d
+}
+
+func (s *tenantState) snapshot() TenantStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := s.stats
+	stats.Active = s.client != nil
+	return stats
+}
+
+// tokenBucket is a rate limiter allowing rate events per second with bursts of
+// up to burst.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int, now time.Time) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: now}
+}
+
+// allow takes a token if one is available.
+func (b *tokenBucket) allow(now time.Time) bool {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = min(b.burst, b.tokens+elapsed*b.rate)
+		b.last = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,247 @@
+package notifox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestClientPool(t *testing.T) {
+	var mu sync.Mutex
+	keys := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		keys[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]++
+		mu.Unlock()
+		json.NewEncoder(w).Encode(AlertResponse{MessageID: "msg"})
+	}))
+	defer server.Close()
+
+	var resolved int
+	resolve := func(ctx context.Context, tenant string) (TenantConfig, error) {
+		resolved++
+		switch tenant {
+		case "acme":
+			return TenantConfig{APIKey: "acme-key", RateLimit: 1, Burst: 2}, nil
+		case "globex":
+			return TenantConfig{APIKey: "globex-key", Budget: 1, BudgetPeriod: time.Hour}, nil
+		}
+		return TenantConfig{}, ErrUnknownTenant
+	}
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	pool := NewClientPool(resolve, WithPoolClientOptions(WithBaseURL(server.URL)), WithPoolIdleTimeout(time.Minute))
+	pool.now = func() time.Time { return now }
+	ctx := context.Background()
+	req := AlertRequest{Audience: "oncall", Alert: "disk full"}
+
+	// acme: burst of 2, then rate limited until a token refills.
+	for i := 0; i < 2; i++ {
+		if _, err := pool.SendAlert(ctx, "acme", req); err != nil {
+			t.Fatalf("SendAlert() unexpected error: %v", err)
+		}
+	}
+	if _, err := pool.SendAlert(ctx, "acme", req); !errors.Is(err, ErrTenantRateLimited) {
+		t.Errorf("expected ErrTenantRateLimited, got %v", err)
+	}
+	now = now.Add(time.Second)
+	if _, err := pool.SendAlert(ctx, "acme", req); err != nil {
+		t.Errorf("SendAlert() after refill unexpected error: %v", err)
+	}
+
+	// globex: one alert per hour.
+	pool.SendAlert(ctx, "globex", req)
+	if _, err := pool.SendAlert(ctx, "globex", req); !errors.Is(err, ErrTenantBudgetExceeded) {
+		t.Errorf("expected ErrTenantBudgetExceeded, got %v", err)
+	}
+
+	if _, err := pool.SendAlert(ctx, "initech", req); !errors.Is(err, ErrUnknownTenant) {
+		t.Errorf("expected ErrUnknownTenant, got %v", err)
+	}
+
+	if keys["acme-key"] != 3 || keys["globex-key"] != 1 {
+		t.Errorf("unexpected requests per key %v", keys)
+	}
+
+	stats := pool.Stats("acme")
+	if stats.Sent != 3 || stats.RateLimited != 1 || !stats.Active {
+		t.Errorf("unexpected acme stats %+v", stats)
+	}
+	if stats := pool.Stats("globex"); stats.Sent != 1 || stats.BudgetExceeded != 1 || stats.BudgetUsed != 1 {
+		t.Errorf("unexpected globex stats %+v", stats)
+	}
+
+	// Idle clients are evicted, but the budget survives re-resolving.
+	resolved = 0
+	now = now.Add(2 * time.Minute)
+	if _, err := pool.SendAlert(ctx, "globex", req); !errors.Is(err, ErrTenantBudgetExceeded) {
+		t.Errorf("expected budget to survive eviction, got %v", err)
+	}
+	if resolved != 1 {
+		t.Errorf("expected globex to be resolved again after eviction, got %d resolves", resolved)
+	}
+	if stats := pool.Stats("acme"); stats.Active || stats.Sent != 3 {
+		t.Errorf("expected idle acme client to be evicted with its stats kept, got %+v", stats)
+	}
+}
+
+func TestClientPoolSharedTransport(t *testing.T) {
+	pool := NewClientPool(func(ctx context.Context, tenant string) (TenantConfig, error) {
+		return TenantConfig{APIKey: tenant + "-key"}, nil
+	})
+
+	a, err := pool.Client(context.Background(), "a")
+	if err != nil {
+		t.Fatalf("Client() unexpected error: %v", err)
+	}
+	b, _ := pool.Client(context.Background(), "b")
+	if a == b || a.httpClient.Transport != b.httpClient.Transport {
+		t.Error("expected distinct clients sharing one transport")
+	}
+}
+
+func TestClientPoolBudgetPeriods(t *testing.T) {
+	var mu sync.Mutex
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	advance := func(d time.Duration) {
+		mu.Lock()
+		now = now.Add(d)
+		mu.Unlock()
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req AlertRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Alert == "slow failure" {
+			// The budget period rolls over while the alert is in flight.
+			advance(2 * time.Hour)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(AlertResponse{MessageID: "msg"})
+	}))
+	defer server.Close()
+
+	pool := NewClientPool(func(ctx context.Context, tenant string) (TenantConfig, error) {
+		return TenantConfig{APIKey: tenant + "-key", Budget: 2, BudgetPeriod: time.Hour}, nil
+	}, WithPoolClientOptions(WithBaseURL(server.URL)), WithPoolIdleTimeout(time.Minute))
+	pool.now = clock
+	ctx := context.Background()
+
+	if _, err := pool.SendAlert(ctx, "acme", AlertRequest{Audience: "oncall", Alert: "slow failure"}); err == nil {
+		t.Fatal("SendAlert() expected error, got nil")
+	}
+	// Starts a new period; the failure above must not be refunded to it.
+	pool.SendAlert(ctx, "acme", AlertRequest{Audience: "oncall", Alert: "disk full"})
+	if stats := pool.Stats("acme"); stats.BudgetUsed != 1 || stats.Failed != 1 || stats.Sent != 1 {
+		t.Errorf("unexpected acme stats %+v", stats)
+	}
+
+	// Idle for a day, the tenant is forgotten.
+	advance(25 * time.Hour)
+	pool.Client(ctx, "globex")
+	if stats := pool.Stats("acme"); stats.Sent != 0 || len(pool.AllStats()) != 1 {
+		t.Errorf("expected acme to be forgotten, got %+v and %d tenants", stats, len(pool.AllStats()))
+	}
+}
+
+func TestClientPoolResolveOutsideLock(t *testing.T) {
+	release := make(chan struct{})
+	var resolves int
+	var mu sync.Mutex
+	pool := NewClientPool(func(ctx context.Context, tenant string) (TenantConfig, error) {
+		mu.Lock()
+		resolves++
+		mu.Unlock()
+		<-release
+		return TenantConfig{APIKey: tenant + "-key"}, nil
+	})
+
+	var wg sync.WaitGroup
+	clients := make([]*Client, 2)
+	for i := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			clients[i], _ = pool.Client(context.Background(), "acme")
+		}()
+	}
+
+	// Stats don't wait for the resolver.
+	deadline := time.Now().Add(time.Second)
+	for pool.Stats("acme").LastUsed.IsZero() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if resolves != 1 || clients[0] == nil || clients[0] != clients[1] {
+		t.Errorf("expected one resolve shared by both callers, got %d resolves", resolves)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	pool.Evict("acme")
+	release = make(chan struct{})
+	defer close(release)
+	go pool.Client(context.Background(), "acme")
+	for {
+		mu.Lock()
+		n := resolves
+		mu.Unlock()
+		if n == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := pool.Client(ctx, "acme"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected waiting caller to stop on cancel, got %v", err)
+	}
+}
+
+func TestClientPoolEvictStopsHealthProbe(t *testing.T) {
+	var mu sync.Mutex
+	probes := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		probes++
+		mu.Unlock()
+	}))
+	defer server.Close()
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return probes
+	}
+
+	pool := NewClientPool(func(ctx context.Context, tenant string) (TenantConfig, error) {
+		return TenantConfig{APIKey: tenant + "-key"}, nil
+	}, WithPoolClientOptions(WithEndpoints(Endpoint{URL: server.URL}), WithHealthProbe(5*time.Millisecond, "/health")))
+
+	if _, err := pool.Client(context.Background(), "acme"); err != nil {
+		t.Fatalf("Client() unexpected error: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for count() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	pool.Evict("acme")
+	time.Sleep(20 * time.Millisecond)
+	before := count()
+	time.Sleep(50 * time.Millisecond)
+	if after := count(); before == 0 || after != before {
+		t.Errorf("expected probing to stop after eviction, got %d then %d probes", before, after)
+	}
+}
@@ -0,0 +1,272 @@
+package notifox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultPreflightInterval is how often a PreflightMonitor runs its checks.
+const DefaultPreflightInterval = 15 * time.Minute
+
+// PreflightError is returned when preflight checks find problems.
+type PreflightError struct {
+	Problems []string
+}
+
+func (e *PreflightError) Error() string {
+	return "preflight failed: " + strings.Join(e.Problems, "; ")
+}
+
+// PreflightReport is the result of Preflight.
+type PreflightReport struct {
+	CheckedAt time.Time
+	// APIKeyValid is false if the API rejected the key.
+	APIKeyValid bool
+	// Balance is nil if it could not be fetched.
+	Balance   *Balance
+	Audiences []AudienceCheck
+	// Problems lists everything that would stop alerts from being delivered.
+	Problems []string
+}
+
+// AudienceCheck is the preflight result for a single audience.
+type AudienceCheck struct {
+	Audience string
+	Exists   bool
+	Verified bool
+	// Channels are the channels the audience has a verified contact method for.
+	Channels []Channel
+	// MissingChannels are required channels the audience cannot be reached on.
+	MissingChannels []Channel
+}
+
+// OK reports whether every check passed.
+func (r *PreflightReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// Err returns a *PreflightError listing the problems, or nil if every check passed.
+func (r *PreflightReport) Err() error {
+	if r.OK() {
+		return nil
+	}
+	return &PreflightError{Problems: r.Problems}
+}
+
+// Preflight checks that the API key is accepted, the account has balance and each
+// audience exists and has at least one verified contact method. Run it at startup
+// so misconfiguration surfaces before a real incident fails to page. The returned
+// error is the report's Err.
+func (c *Client) Preflight(ctx context.Context, audiences ...string) (*PreflightReport, error) {
+	return c.PreflightChannels(ctx, nil, audiences...)
+}
+
+// PreflightChannels is like Preflight, but also requires each audience to be
+// verified for every one of channels.
+func (c *Client) PreflightChannels(ctx context.Context, channels []Channel, audiences ...string) (*PreflightReport, error) {
+	report := &PreflightReport{CheckedAt: time.Now(), APIKeyValid: true}
+
+	balance, err := c.GetBalance(ctx)
+	switch {
+	case errors.Is(err, ErrUnauthorized):
+		report.APIKeyValid = false
+		report.Problems = append(report.Problems, "API key was rejected")
+		// Nothing else can be checked without a valid key.
+		return report, report.Err()
+	case err != nil:
+		report.Problems = append(report.Problems, fmt.Sprintf("could not check balance: %v", err))
+	case balance.Amount <= 0:
+		report.Balance = balance
+		report.Problems = append(report.Problems, fmt.Sprintf("account balance is %.2f %s", balance.Amount, balance.Currency))
+	default:
+		report.Balance = balance
+	}
+
+	for _, name := range audiences {
+		check := AudienceCheck{Audience: name}
+
+		audience, err := c.GetAudience(ctx, name)
+		switch {
+		case isNotFound(err), errors.Is(err, ErrInvalidAudience):
+			report.Problems = append(report.Problems, fmt.Sprintf("audience %q does not exist", name))
+		case err != nil:
+			report.Problems = append(report.Problems, fmt.Sprintf("could not check audience %q: %v", name, err))
+		default:
+			check.Exists = true
+			check.Channels = audience.VerifiedChannels()
+			check.Verified = audience.Verified || len(check.Channels) > 0
+			for _, ch := range channels {
+				if !slices.Contains(check.Channels, ch) {
+					check.MissingChannels = append(check.MissingChannels, ch)
+				}
+			}
+
+			switch {
+			case !check.Verified:
+				report.Problems = append(report.Problems, fmt.Sprintf("audience %q is not verified", name))
+			case len(check.MissingChannels) > 0:
+				report.Problems = append(report.Problems, fmt.Sprintf("audience %q is not verified for %s", name, joinChannels(check.MissingChannels)))
+			}
+		}
+
+		report.Audiences = append(report.Audiences, check)
+	}
+
+	return report, report.Err()
+}
+
+// MustPreflight is like Preflight but panics if any check fails. It is intended
+// for use in main:
+//
+//	client.MustPreflight(ctx, "oncall-team")
+func (c *Client) MustPreflight(ctx context.Context, audiences ...string) *PreflightReport {
+	report, err := c.Preflight(ctx, audiences...)
+	if err != nil {
+		panic(err)
+	}
+	return report
+}
+
+func joinChannels(channels []Channel) string {
+	s := make([]string, len(channels))
+	for i, ch := range channels {
+		s[i] = string(ch)
+	}
+	return strings.Join(s, ", ")
+}
+
+// PreflightOption is a function that configures a PreflightMonitor.
+type PreflightOption func(*PreflightMonitor)
+
+// WithPreflightAudiences sets the audiences checked by the monitor.
+func WithPreflightAudiences(audiences ...string) PreflightOption {
+	return func(m *PreflightMonitor) {
+		m.audiences = audiences
+	}
+}
+
+// WithPreflightChannels sets the channels every audience must be verified for.
+func WithPreflightChannels(channels ...Channel) PreflightOption {
+	return func(m *PreflightMonitor) {
+		m.channels = channels
+	}
+}
+
+// WithPreflightInterval sets how often the checks run.
+// Non-positive values use DefaultPreflightInterval.
+func WithPreflightInterval(interval time.Duration) PreflightOption {
+	return func(m *PreflightMonitor) {
+		if interval <= 0 {
+			interval = DefaultPreflightInterval
+		}
+		m.interval = interval
+	}
+}
+
+// WithPreflightAlertAudience sets the audience and channel of failure alerts sent
+// through the fallback sender.
+func WithPreflightAlertAudience(audience string, channel Channel) PreflightOption {
+	return func(m *PreflightMonitor) {
+		m.alertAudience = audience
+		m.alertChannel = channel
+	}
+}
+
+// WithPreflightReportHandler sets a function called with every report.
+func WithPreflightReportHandler(fn func(*PreflightReport)) PreflightOption {
+	return func(m *PreflightMonitor) {
+		m.onReport = fn
+	}
+}
+
+// WithPreflightErrorHandler sets a function called when sending an alert fails.
+// Failed alerts are retried on the next check.
+func WithPreflightErrorHandler(fn func(err error)) PreflightOption {
+	return func(m *PreflightMonitor) {
+		m.onError = fn
+	}
+}
+
+// PreflightMonitor runs preflight checks periodically and alerts through a
+// fallback sender when they start failing, and again when they pass. The
+// fallback should not depend on the checked client: if its key was revoked,
+// alerts through it would fail too.
+type PreflightMonitor struct {
+	client        *Client
+	fallback      AlertSender
+	audiences     []string
+	channels      []Channel
+	interval      time.Duration
+	alertAudience string
+	alertChannel  Channel
+	onReport      func(*PreflightReport)
+	onError       func(err error)
+
+	// failing is set once a failure alert has been sent.
+	failing bool
+}
+
+// NewPreflightMonitor creates a monitor that checks client and alerts through fallback.
+func NewPreflightMonitor(client *Client, fallback AlertSender, opts ...PreflightOption) *PreflightMonitor {
+	m := &PreflightMonitor{
+		client:   client,
+		fallback: fallback,
+		interval: DefaultPreflightInterval,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Run runs the checks immediately and then every interval until ctx is done.
+func (m *PreflightMonitor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// check runs the checks once and sends a failure or recovery alert on a change.
+func (m *PreflightMonitor) check(ctx context.Context) {
+	report, err := m.client.PreflightChannels(ctx, m.channels, m.audiences...)
+	if ctx.Err() != nil {
+		return
+	}
+	if m.onReport != nil {
+		m.onReport(report)
+	}
+
+	var msg string
+	switch {
+	case err != nil && !m.failing:
+		msg = fmt.Sprintf("Notifox preflight failed: %s", strings.Join(report.Problems, "; "))
+	case err == nil && m.failing:
+		msg = "Notifox preflight passed again"
+	default:
+		return
+	}
+
+	_, sendErr := m.fallback.SendAlert(ctx, AlertRequest{Audience: m.alertAudience, Channel: m.alertChannel, Alert: msg})
+	if sendErr != nil {
+		if m.onError != nil {
+			m.onError(sendErr)
+		}
+		return
+	}
+	m.failing = err != nil
+}
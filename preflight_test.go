@@ -0,0 +1,159 @@
+package notifox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newPreflightServer(t *testing.T, balance float64) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /account/balance", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-api-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte("Unauthorized"))
+			return
+		}
+		json.NewEncoder(w).Encode(Balance{Amount: balance, Currency: "USD"})
+	})
+	mux.HandleFunc("GET /audiences/{id}", func(w http.ResponseWriter, r *http.Request) {
+		switch r.PathValue("id") {
+		case "oncall":
+			json.NewEncoder(w).Encode(Audience{ID: "oncall", Verified: true, ContactMethods: []ContactMethod{
+				{Type: ContactPhone, Value: "+15551234567", Verified: true},
+			}})
+		case "pending":
+			json.NewEncoder(w).Encode(Audience{ID: "pending", ContactMethods: []ContactMethod{
+				{Type: ContactEmail, Value: "new@example.com"},
+			}})
+		case "deleted":
+			// A bare 404 without an error code.
+			http.NotFound(w, r)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "audience not found", Code: CodeAudienceNotFound})
+		}
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestPreflight(t *testing.T) {
+	server := newPreflightServer(t, 12.5)
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+	ctx := context.Background()
+
+	report, err := client.Preflight(ctx, "oncall")
+	if err != nil {
+		t.Fatalf("Preflight() unexpected error: %v", err)
+	}
+	if !report.APIKeyValid || report.Balance == nil || report.Balance.Amount != 12.5 {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if len(report.Audiences) != 1 || !report.Audiences[0].Verified {
+		t.Errorf("unexpected audience checks %+v", report.Audiences)
+	}
+
+	report, err = client.PreflightChannels(ctx, []Channel{SMS, Email}, "oncall", "pending", "typo", "deleted")
+	var preflightErr *PreflightError
+	if !errors.As(err, &preflightErr) {
+		t.Fatalf("expected PreflightError, got %v", err)
+	}
+	want := []string{
+		`audience "oncall" is not verified for email`,
+		`audience "pending" is not verified`,
+		`audience "typo" does not exist`,
+		`audience "deleted" does not exist`,
+	}
+	if strings.Join(report.Problems, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Problems = %q, want %q", report.Problems, want)
+	}
+}
+
+func TestPreflightRejectedKey(t *testing.T) {
+	server := newPreflightServer(t, 12.5)
+	client, err := NewClientWithOptions(WithAPIKey("revoked-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	report, err := client.Preflight(context.Background(), "oncall")
+	if err == nil {
+		t.Fatal("Preflight() expected error, got nil")
+	}
+	if report.APIKeyValid {
+		t.Error("expected APIKeyValid to be false")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("MustPreflight() expected panic")
+		}
+	}()
+	client.MustPreflight(context.Background(), "oncall")
+}
+
+func TestPreflightMonitor(t *testing.T) {
+	server := newPreflightServer(t, 0)
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	fallback := &recordingSender{}
+	m := NewPreflightMonitor(client, fallback,
+		WithPreflightAudiences("oncall"),
+		WithPreflightAlertAudience("platform", Email),
+	)
+
+	m.check(context.Background())
+	m.check(context.Background())
+
+	reqs := fallback.requests()
+	if len(reqs) != 1 {
+		t.Fatalf("expected 1 fallback alert, got %d", len(reqs))
+	}
+	if reqs[0].Audience != "platform" || !strings.Contains(reqs[0].Alert, "account balance is 0.00 USD") {
+		t.Errorf("unexpected fallback alert %+v", reqs[0])
+	}
+}
+
+func TestPreflightMonitorSendError(t *testing.T) {
+	server := newPreflightServer(t, 0)
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	var sends int
+	var errs []error
+	fallback := AlertSenderFunc(func(ctx context.Context, req AlertRequest) (*AlertResponse, error) {
+		sends++
+		return nil, errors.New("chat unreachable")
+	})
+	m := NewPreflightMonitor(client, fallback,
+		WithPreflightAudiences("oncall"),
+		WithPreflightInterval(0),
+		WithPreflightErrorHandler(func(err error) { errs = append(errs, err) }),
+	)
+	if m.interval != DefaultPreflightInterval {
+		t.Errorf("interval = %v, want %v", m.interval, DefaultPreflightInterval)
+	}
+
+	// A failed alert is reported and retried on the next check.
+	m.check(context.Background())
+	m.check(context.Background())
+	if sends != 2 || len(errs) != 2 || errs[0].Error() != "chat unreachable" {
+		t.Errorf("got %d sends and errors %v, want 2 of each", sends, errs)
+	}
+}
@@ -0,0 +1,318 @@
+package notifox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPanicRateLimit is the default minimum time between panic alerts for one route.
+	DefaultPanicRateLimit = time.Minute
+	// DefaultPanicDedupWindow is the default time an identical panic is suppressed for.
+	DefaultPanicDedupWindow = 10 * time.Minute
+	// DefaultPanicStackFrames is the default number of stack frames included in panic alerts.
+	DefaultPanicStackFrames = 5
+	// DefaultRequestIDHeader is the default header read and written for request IDs.
+	DefaultRequestIDHeader = RequestIDHeader
+)
+
+// PanicOption is a function that configures a PanicRecoverer.
+type PanicOption func(*PanicRecoverer)
+
+// WithPanicAudience sets the audience for panic alerts.
+func WithPanicAudience(audience string) PanicOption {
+	return func(p *PanicRecoverer) {
+		p.audience = audience
+	}
+}
+
+// WithPanicChannel sets the channel for panic alerts.
+func WithPanicChannel(channel Channel) PanicOption {
+	return func(p *PanicRecoverer) {
+		p.channel = channel
+	}
+}
+
+// WithPanicRateLimit sets the minimum time between alerts for a single route.
+func WithPanicRateLimit(interval time.Duration) PanicOption {
+	return func(p *PanicRecoverer) {
+		p.routeLimit.interval = interval
+	}
+}
+
+// WithPanicDedupWindow sets how long an identical panic (same route and value) is suppressed.
+func WithPanicDedupWindow(window time.Duration) PanicOption {
+	return func(p *PanicRecoverer) {
+		p.dedup.interval = window
+	}
+}
+
+// WithPanicStackFrames sets how many stack frames are included in alerts.
+func WithPanicStackFrames(frames int) PanicOption {
+	return func(p *PanicRecoverer) {
+		p.stackFrames = frames
+	}
+}
+
+// WithPanicRouteFunc sets how the route is derived from a request. By default the
+// ServeMux pattern is used when available, otherwise the URL path.
+func WithPanicRouteFunc(fn func(*http.Request) string) PanicOption {
+	return func(p *PanicRecoverer) {
+		p.routeFunc = fn
+	}
+}
+
+// WithRequestIDHeader sets the header used to read and echo request IDs.
+func WithRequestIDHeader(header string) PanicOption {
+	return func(p *PanicRecoverer) {
+		p.requestIDHeader = header
+	}
+}
+
+// WithPanicErrorHandler sets a function called when sending a panic alert fails.
+func WithPanicErrorHandler(fn func(err error)) PanicOption {
+	return func(p *PanicRecoverer) {
+		p.onError = fn
+	}
+}
+
+// PanicRecoverer is net/http middleware that recovers panics, responds with 500
+// and sends an alert in the background. Alerts are rate limited per route and
+// identical panics are deduplicated, so a hot path panicking continuously
+// produces one alert per interval rather than one per request.
+type PanicRecoverer struct {
+	sender          AlertSender
+	audience        string
+	channel         Channel
+	stackFrames     int
+	routeFunc       func(*http.Request) string
+	requestIDHeader string
+	onError         func(err error)
+	now             func() time.Time
+
+	routeLimit *alertThrottle
+	dedup      *alertThrottle
+	// wg tracks in-flight alerts.
+	wg sync.WaitGroup
+}
+
+// NewPanicRecoverer creates panic-recovery middleware that alerts through sender.
+func NewPanicRecoverer(sender AlertSender, opts ...PanicOption) *PanicRecoverer {
+	p := &PanicRecoverer{
+		sender:          sender,
+		stackFrames:     DefaultPanicStackFrames,
+		routeFunc:       requestRoute,
+		requestIDHeader: DefaultRequestIDHeader,
+		now:             time.Now,
+		routeLimit:      newAlertThrottle(DefaultPanicRateLimit),
+		dedup:           newAlertThrottle(DefaultPanicDedupWindow),
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Middleware wraps next so that panics are recovered and alerted on.
+func (p *PanicRecoverer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(p.requestIDHeader)
+		if requestID == "" {
+			requestID = newRandomID()
+		}
+		w.Header().Set(p.requestIDHeader, requestID)
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				// Deliberate abort; let net/http handle it.
+				panic(v)
+			}
+
+			stack := p.stack()
+			if !sw.wroteHeader {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
+			p.report(r, requestID, v, stack)
+		}()
+
+		next.ServeHTTP(sw, r)
+	})
+}
+
+// report sends an alert for a recovered panic unless it is throttled.
+func (p *PanicRecoverer) report(r *http.Request, requestID string, v any, stack string) {
+	route := p.routeFunc(r)
+	value := fmt.Sprint(v)
+	now := p.now()
+
+	if ok, _ := p.dedup.allow(route+"\x00"+value, now); !ok {
+		p.routeLimit.suppress(route, now)
+		return
+	}
+	ok, suppressed := p.routeLimit.allow(route, now)
+	if !ok {
+		return
+	}
+
+	msg := fmt.Sprintf("Panic in %s %s: %s (request %s)", r.Method, route, value, requestID)
+	if suppressed > 0 {
+		msg += fmt.Sprintf(" [+%d suppressed]", suppressed)
+	}
+	if stack != "" {
+		msg += "\n" + stack
+	}
+	req := AlertRequest{Audience: p.audience, Channel: p.channel, Alert: msg}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), DefaultTimeout)
+		defer cancel()
+
+		if _, err := p.sender.SendAlert(ctx, req); err != nil && p.onError != nil {
+			p.onError(err)
+		}
+	}()
+}
+
+// Wait blocks until every alert sent in the background so far has finished. Call
+// it after http.Server.Shutdown so that alerts for the last panics aren't lost on exit.
+func (p *PanicRecoverer) Wait() {
+	p.wg.Wait()
+}
+
+// stack returns the panicking goroutine's stack, trimmed to the configured number
+// of frames and without runtime frames. It must be called from the deferred function.
+func (p *PanicRecoverer) stack() string {
+	if p.stackFrames <= 0 {
+		return ""
+	}
+
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var lines []string
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "runtime.") {
+			file := frame.File
+			if i := strings.LastIndex(file, "/"); i >= 0 {
+				file = file[i+1:]
+			}
+			lines = append(lines, fmt.Sprintf("%s %s:%d", shortFuncName(frame.Function), file, frame.Line))
+		}
+		if !more || len(lines) >= p.stackFrames {
+			break
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// shortFuncName strips the import path from a function name.
+func shortFuncName(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// requestRoute returns the ServeMux pattern that matched r, or its path.
+func requestRoute(r *http.Request) string {
+	if r.Pattern != "" {
+		return r.Pattern
+	}
+	return r.URL.Path
+}
+
+// newRandomID returns a random 16-character hex ID.
+func newRandomID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// alertThrottle allows at most one alert per key per interval and counts the
+// alerts it suppressed in between.
+type alertThrottle struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	keys map[string]*throttleKey
+}
+
+// throttleKey is an alertThrottle's state for one key.
+type throttleKey struct {
+	last       time.Time
+	suppressed int
+	// updated is when an alert for the key was last allowed or suppressed.
+	updated time.Time
+}
+
+func newAlertThrottle(interval time.Duration) *alertThrottle {
+	return &alertThrottle{
+		interval: interval,
+		keys:     make(map[string]*throttleKey),
+	}
+}
+
+// allow reports whether an alert for key may be sent at now and, if so, how many
+// alerts for key were suppressed since the last one.
+func (t *alertThrottle) allow(key string, now time.Time) (bool, int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	k := t.keys[key]
+	if k != nil && !k.last.IsZero() && now.Sub(k.last) < t.interval {
+		k.suppressed++
+		k.updated = now
+		return false, 0
+	}
+
+	suppressed := 0
+	if k != nil {
+		suppressed = k.suppressed
+	}
+	t.prune(now)
+	t.keys[key] = &throttleKey{last: now, updated: now}
+	return true, suppressed
+}
+
+// suppress counts an alert for key that was dropped for another reason.
+func (t *alertThrottle) suppress(key string, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	k := t.keys[key]
+	if k == nil {
+		k = &throttleKey{}
+		t.keys[key] = k
+	}
+	k.suppressed++
+	k.updated = now
+}
+
+// prune drops keys without alerts for an interval so the map doesn't grow
+// without bound. t.mu must be held.
+func (t *alertThrottle) prune(now time.Time) {
+	for key, k := range t.keys {
+		if now.Sub(k.updated) >= t.interval {
+			delete(t.keys, key)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package notifox
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPanicRecoverer(t *testing.T) {
+	sender := &recordingSender{}
+	p := NewPanicRecoverer(sender, WithPanicAudience("oncall"), WithPanicChannel(SMS))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		panic("nil user")
+	})
+	handler := p.Middleware(mux)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set(DefaultRequestIDHeader, "req-123")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	p.Wait()
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if got := rec.Header().Get(DefaultRequestIDHeader); got != "req-123" {
+		t.Errorf("request ID header = %q, want %q", got, "req-123")
+	}
+
+	reqs := sender.requests()
+	if len(reqs) != 1 {
+		t.Fatalf("expected 1 alert, got %d", len(reqs))
+	}
+	for _, want := range []string{"GET /users/{id}", "nil user", "req-123", "recover_test.go"} {
+		if !strings.Contains(reqs[0].Alert, want) {
+			t.Errorf("alert %q does not contain %q", reqs[0].Alert, want)
+		}
+	}
+}
+
+func TestPanicRecovererThrottles(t *testing.T) {
+	sender := &recordingSender{}
+	p := NewPanicRecoverer(sender, WithPanicAudience("oncall"))
+
+	handler := p.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom: " + r.URL.Query().Get("n"))
+	}))
+
+	for i := 0; i < 1000; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/hot", nil))
+	}
+	// A different panic on the same route is still rate limited.
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/hot?n=2", nil))
+	// Other routes are not affected.
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/cold", nil))
+	p.Wait()
+
+	if got := len(sender.requests()); got != 2 {
+		t.Errorf("expected 2 alerts (one per route), got %d", got)
+	}
+}
+
+func TestPanicRecovererPassesThrough(t *testing.T) {
+	sender := &recordingSender{}
+	p := NewPanicRecoverer(sender, WithPanicAudience("oncall"))
+
+	handler := p.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		if _, ok := w.(http.Flusher); !ok {
+			t.Error("expected the wrapped writer to implement http.Flusher")
+		}
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("Flush() unexpected error: %v", err)
+		}
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	p.Wait()
+
+	if rec.Code != http.StatusTeapot {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusTeapot)
+	}
+	if rec.Header().Get(DefaultRequestIDHeader) == "" {
+		t.Error("expected a generated request ID header")
+	}
+	if got := len(sender.requests()); got != 0 {
+		t.Errorf("expected no alerts, got %d", got)
+	}
+}
+
+func TestAlertThrottlePrunes(t *testing.T) {
+	throttle := newAlertThrottle(time.Minute)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 100; i++ {
+		throttle.suppress(fmt.Sprintf("route-%d", i), now)
+	}
+	throttle.allow("a", now)
+	if ok, _ := throttle.allow("a", now.Add(time.Second)); ok {
+		t.Error("expected a second alert within the interval to be suppressed")
+	}
+
+	ok, suppressed := throttle.allow("b", now.Add(2*time.Minute))
+	if !ok || suppressed != 0 || len(throttle.keys) != 1 {
+		t.Errorf("allow() = %v, %d with %d keys, want expired keys pruned", ok, suppressed, len(throttle.keys))
+	}
+}
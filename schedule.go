@@ -0,0 +1,390 @@
+package notifox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// scheduledPath is the server-side scheduling endpoint.
+const scheduledPath = "/alert/scheduled"
+
+// ErrSchedulingUnsupported is returned when the server has no scheduling endpoint.
+// Use a Scheduler to schedule alerts client-side instead.
+var ErrSchedulingUnsupported = errors.New("notifox: scheduled alerts are not supported by the server")
+
+// ScheduledAlert is an alert waiting to be sent.
+type ScheduledAlert struct {
+	ID        string    `json:"id"`
+	Audience  string    `json:"audience"`
+	Alert     string    `json:"alert"`
+	Channel   Channel   `json:"channel"`
+	SendAt    time.Time `json:"send_at"`
+	CreatedAt time.Time `json:"created_at"`
+	// IdempotencyKey is sent with the alert, so a send retried by a Scheduler,
+	// even after a restart, is delivered once.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+}
+
+// scheduledList is the response from listing scheduled alerts.
+type scheduledList struct {
+	Alerts []ScheduledAlert `json:"alerts"`
+}
+
+// CancelScheduled cancels an alert scheduled with AlertRequest.SendAt or Delay.
+// The ID is the MessageID returned by SendAlert.
+func (c *Client) CancelScheduled(ctx context.Context, id string) error {
+	if id == "" {
+		return fmt.Errorf("scheduled alert ID cannot be empty")
+	}
+
+	err := c.doWithRetry(ctx, http.MethodDelete, scheduledPath+"/"+url.PathEscape(id), nil, nil)
+	if err != nil && isMethodUnsupported(err) {
+		return fmt.Errorf("%w: %w", ErrSchedulingUnsupported, err)
+	}
+	return err
+}
+
+// ListScheduled returns the alerts scheduled on the server that have not been sent yet.
+func (c *Client) ListScheduled(ctx context.Context) ([]ScheduledAlert, error) {
+	var list scheduledList
+	if err := c.doWithRetry(ctx, http.MethodGet, scheduledPath, nil, &list); err != nil {
+		if isUnsupported(err) {
+			return nil, fmt.Errorf("%w: %w", ErrSchedulingUnsupported, err)
+		}
+		return nil, err
+	}
+
+	return list.Alerts, nil
+}
+
+// isUnsupported reports whether err means the endpoint does not exist.
+func isUnsupported(err error) bool {
+	return isNotFound(err) || isMethodUnsupported(err)
+}
+
+// isMethodUnsupported reports whether err is a 405 or 501 response.
+func isMethodUnsupported(err error) bool {
+	var apiErr *NotifoxAPIError
+	return errors.As(err, &apiErr) &&
+		(apiErr.StatusCode == http.StatusMethodNotAllowed || apiErr.StatusCode == http.StatusNotImplemented)
+}
+
+// SchedulerOption is a function that configures a Scheduler.
+type SchedulerOption func(*Scheduler)
+
+// WithSchedulerMaxLateness drops alerts that are more than lateness overdue, e.g.
+// after a long restart, instead of sending them late. Zero means always send.
+func WithSchedulerMaxLateness(lateness time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.maxLateness = lateness
+	}
+}
+
+// WithSchedulerRetryInterval sets how long to wait before retrying a failed send.
+func WithSchedulerRetryInterval(interval time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.retryInterval = interval
+	}
+}
+
+// WithSchedulerErrorHandler sets a function called when an alert fails to send or
+// is dropped, or the schedule cannot be saved.
+func WithSchedulerErrorHandler(fn func(alert ScheduledAlert, err error)) SchedulerOption {
+	return func(s *Scheduler) {
+		s.onError = fn
+	}
+}
+
+// Scheduler schedules alerts client-side, for servers without a scheduling
+// endpoint. Pending alerts are saved to a JSON file so they survive restarts;
+// alerts that came due while the process was down are sent when Run starts.
+type Scheduler struct {
+	sender        AlertSender
+	path          string
+	maxLateness   time.Duration
+	retryInterval time.Duration
+	onError       func(alert ScheduledAlert, err error)
+	now           func() time.Time
+
+	mu     sync.Mutex
+	alerts map[string]ScheduledAlert
+	// retryAt delays alerts whose last send failed.
+	retryAt map[string]time.Time
+	// sending holds the alerts being sent, which can no longer be canceled.
+	sending map[string]bool
+	wake    chan struct{}
+}
+
+// NewScheduler creates a scheduler that sends through sender and persists pending
+// alerts to path, loading any saved by a previous run.
+func NewScheduler(sender AlertSender, path string, opts ...SchedulerOption) (*Scheduler, error) {
+	s := &Scheduler{
+		sender:        sender,
+		path:          path,
+		retryInterval: time.Minute,
+		now:           time.Now,
+		alerts:        make(map[string]ScheduledAlert),
+		retryAt:       make(map[string]time.Time),
+		sending:       make(map[string]bool),
+		wake:          make(chan struct{}, 1),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("failed to load schedule: %w", err)
+	default:
+		var alerts []ScheduledAlert
+		if err := json.Unmarshal(data, &alerts); err != nil {
+			return nil, fmt.Errorf("failed to load schedule: %w", err)
+		}
+		for _, a := range alerts {
+			if a.IdempotencyKey == "" {
+				// Saved by an older version; the ID is just as stable.
+				a.IdempotencyKey = a.ID
+			}
+			s.alerts[a.ID] = a
+		}
+	}
+
+	return s, nil
+}
+
+// Schedule saves req to be sent at the given time and returns the scheduled alert.
+// req.SendAt and req.Delay are ignored. An idempotency key is generated if
+// req.IdempotencyKey is empty.
+func (s *Scheduler) Schedule(req AlertRequest, at time.Time) (*ScheduledAlert, error) {
+	if req.Audience == "" {
+		return nil, fmt.Errorf("audience cannot be empty")
+	}
+	if req.Alert == "" {
+		return nil, fmt.Errorf("alert message cannot be empty")
+	}
+
+	a := ScheduledAlert{
+		ID:             newRandomID(),
+		Audience:       req.Audience,
+		Alert:          req.Alert,
+		Channel:        req.Channel,
+		SendAt:         at,
+		CreatedAt:      s.now(),
+		IdempotencyKey: req.IdempotencyKey,
+	}
+	if a.IdempotencyKey == "" {
+		a.IdempotencyKey = newRandomID()
+	}
+
+	s.mu.Lock()
+	s.alerts[a.ID] = a
+	err := s.save()
+	if err != nil {
+		delete(s.alerts, a.ID)
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+	s.notify()
+	return &a, nil
+}
+
+// Cancel removes a scheduled alert. It fails if the alert is already being sent.
+func (s *Scheduler) Cancel(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.alerts[id]
+	if !ok {
+		return fmt.Errorf("unknown scheduled alert %q", id)
+	}
+	if s.sending[id] {
+		return fmt.Errorf("scheduled alert %q is being sent", id)
+	}
+
+	delete(s.alerts, id)
+	if err := s.save(); err != nil {
+		s.alerts[id] = a
+		return err
+	}
+	delete(s.retryAt, id)
+	return nil
+}
+
+// List returns the pending alerts, soonest first.
+func (s *Scheduler) List() []ScheduledAlert {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sorted()
+}
+
+// Run sends alerts as they come due until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) error {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-s.wake:
+		case <-timer.C:
+		}
+
+		next := s.sendDue(ctx)
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		if !next.IsZero() {
+			timer.Reset(max(next.Sub(s.now()), 0))
+		}
+	}
+}
+
+// sendDue sends every due alert and returns when the next one is due (zero if none).
+func (s *Scheduler) sendDue(ctx context.Context) time.Time {
+	now := s.now()
+
+	s.mu.Lock()
+	var due []ScheduledAlert
+	for _, a := range s.sorted() {
+		if !a.SendAt.After(now) && !s.retryAt[a.ID].After(now) {
+			due = append(due, a)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, a := range due {
+		// Earlier sends take time; skip alerts canceled since the list was made.
+		s.mu.Lock()
+		_, pending := s.alerts[a.ID]
+		if pending {
+			s.sending[a.ID] = true
+		}
+		s.mu.Unlock()
+		if !pending {
+			continue
+		}
+
+		if s.maxLateness > 0 && now.Sub(a.SendAt) > s.maxLateness {
+			s.finish(a, fmt.Errorf("dropped scheduled alert %s: %s overdue", a.ID, now.Sub(a.SendAt).Round(time.Second)))
+			continue
+		}
+
+		_, err := s.sender.SendAlert(ctx, AlertRequest{Audience: a.Audience, Alert: a.Alert, Channel: a.Channel, IdempotencyKey: a.IdempotencyKey})
+		if err != nil {
+			s.mu.Lock()
+			delete(s.sending, a.ID)
+			if ctx.Err() == nil {
+				s.retryAt[a.ID] = now.Add(s.retryInterval)
+			}
+			s.mu.Unlock()
+			if ctx.Err() != nil {
+				break
+			}
+			if s.onError != nil {
+				s.onError(a, err)
+			}
+			continue
+		}
+		s.finish(a, nil)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var next time.Time
+	for _, a := range s.alerts {
+		at := a.SendAt
+		if r := s.retryAt[a.ID]; r.After(at) {
+			at = r
+		}
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	return next
+}
+
+// finish removes a sent or dropped alert and reports err, if any.
+func (s *Scheduler) finish(a ScheduledAlert, err error) {
+	s.mu.Lock()
+	delete(s.alerts, a.ID)
+	delete(s.retryAt, a.ID)
+	delete(s.sending, a.ID)
+	saveErr := s.save()
+	s.mu.Unlock()
+
+	if s.onError == nil {
+		return
+	}
+	if err != nil {
+		s.onError(a, err)
+	}
+	if saveErr != nil {
+		s.onError(a, saveErr)
+	}
+}
+
+// sorted returns the pending alerts, soonest first. s.mu must be held.
+func (s *Scheduler) sorted() []ScheduledAlert {
+	alerts := make([]ScheduledAlert, 0, len(s.alerts))
+	for _, a := range s.alerts {
+		alerts = append(alerts, a)
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].SendAt.Before(alerts[j].SendAt) })
+	return alerts
+}
+
+// save writes the pending alerts to the schedule file atomically. s.mu must be held.
+func (s *Scheduler) save() error {
+	data, err := json.MarshalIndent(s.sorted(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to save schedule: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save schedule: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save schedule: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save schedule: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to save schedule: %w", err)
+	}
+	return nil
+}
+
+// notify wakes Run to recompute the next due time.
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,162 @@
+package notifox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSendAlertScheduled(t *testing.T) {
+	var got AlertRequest
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /alert/scheduled", func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		json.NewEncoder(w).Encode(AlertResponse{MessageID: "sched-1", ScheduledAt: got.SendAt})
+	})
+	mux.HandleFunc("GET /alert/scheduled", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(scheduledList{Alerts: []ScheduledAlert{{ID: "sched-1", Audience: "oncall"}}})
+	})
+	mux.HandleFunc("DELETE /alert/scheduled/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != "sched-1" {
+			t.Errorf("expected sched-1, got %s", r.PathValue("id"))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+	ctx := context.Background()
+
+	before := time.Now()
+	resp, err := client.SendAlert(ctx, AlertRequest{Audience: "oncall", Alert: "Maintenance in 15 minutes", Delay: time.Hour})
+	if err != nil {
+		t.Fatalf("SendAlert() unexpected error: %v", err)
+	}
+	if got.SendAt == nil || got.SendAt.Before(before.Add(time.Hour)) {
+		t.Errorf("expected send_at an hour from now, got %v", got.SendAt)
+	}
+	if resp.ScheduledAt == nil {
+		t.Error("expected ScheduledAt in response")
+	}
+
+	alerts, err := client.ListScheduled(ctx)
+	if err != nil {
+		t.Fatalf("ListScheduled() unexpected error: %v", err)
+	}
+	if len(alerts) != 1 || alerts[0].ID != "sched-1" {
+		t.Errorf("unexpected scheduled alerts %+v", alerts)
+	}
+
+	if err := client.CancelScheduled(ctx, resp.MessageID); err != nil {
+		t.Errorf("CancelScheduled() unexpected error: %v", err)
+	}
+
+	sendAt := time.Now()
+	if _, err := client.SendAlert(ctx, AlertRequest{Audience: "oncall", Alert: "x", SendAt: &sendAt, Delay: time.Minute}); err == nil {
+		t.Error("SendAlert() expected error when both SendAt and Delay are set")
+	}
+}
+
+func TestSendAlertSchedulingUnsupported(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	_, err = client.SendAlert(context.Background(), AlertRequest{Audience: "oncall", Alert: "later", Delay: time.Minute})
+	if !errors.Is(err, ErrSchedulingUnsupported) {
+		t.Errorf("expected ErrSchedulingUnsupported, got %v", err)
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedule.json")
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	sender := &recordingSender{}
+
+	s, err := NewScheduler(sender, path)
+	if err != nil {
+		t.Fatalf("NewScheduler() unexpected error: %v", err)
+	}
+	s.now = func() time.Time { return now }
+
+	soon, _ := s.Schedule(AlertRequest{Audience: "oncall", Alert: "soon"}, now.Add(time.Minute))
+	later, _ := s.Schedule(AlertRequest{Audience: "oncall", Alert: "later"}, now.Add(time.Hour))
+	cancelled, _ := s.Schedule(AlertRequest{Audience: "oncall", Alert: "cancelled"}, now.Add(time.Minute))
+	if err := s.Cancel(cancelled.ID); err != nil {
+		t.Fatalf("Cancel() unexpected error: %v", err)
+	}
+
+	// A restarted process picks up the saved schedule.
+	s, err = NewScheduler(sender, path)
+	if err != nil {
+		t.Fatalf("NewScheduler() unexpected error: %v", err)
+	}
+	s.now = func() time.Time { return now }
+	if got := s.List(); len(got) != 2 || got[0].ID != soon.ID || got[1].ID != later.ID {
+		t.Fatalf("unexpected schedule after reload %+v", got)
+	}
+
+	now = now.Add(2 * time.Minute)
+	next := s.sendDue(context.Background())
+	if !next.Equal(later.SendAt) {
+		t.Errorf("next due = %v, want %v", next, later.SendAt)
+	}
+
+	reqs := sender.requests()
+	if len(reqs) != 1 || reqs[0].Alert != "soon" || reqs[0].IdempotencyKey == "" || reqs[0].IdempotencyKey != soon.IdempotencyKey {
+		t.Errorf("expected only the due alert to be sent with its saved key, got %+v", reqs)
+	}
+	if got := s.List(); len(got) != 1 || got[0].ID != later.ID {
+		t.Errorf("unexpected schedule after sending %+v", got)
+	}
+}
+
+func TestSchedulerCancelDuringSend(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	var s *Scheduler
+	var second *ScheduledAlert
+	var sent []string
+	var cancelErr error
+	sender := AlertSenderFunc(func(ctx context.Context, req AlertRequest) (*AlertResponse, error) {
+		sent = append(sent, req.Alert)
+		if req.Alert == "first" {
+			// The second alert is canceled while the first is in flight.
+			if err := s.Cancel(second.ID); err != nil {
+				t.Errorf("Cancel() unexpected error: %v", err)
+			}
+			cancelErr = s.Cancel(s.List()[0].ID)
+		}
+		return &AlertResponse{MessageID: "msg"}, nil
+	})
+
+	s, err := NewScheduler(sender, filepath.Join(t.TempDir(), "schedule.json"))
+	if err != nil {
+		t.Fatalf("NewScheduler() unexpected error: %v", err)
+	}
+	s.now = func() time.Time { return now }
+
+	s.Schedule(AlertRequest{Audience: "oncall", Alert: "first"}, now)
+	second, _ = s.Schedule(AlertRequest{Audience: "oncall", Alert: "second"}, now.Add(time.Second))
+	now = now.Add(time.Minute)
+	s.sendDue(context.Background())
+
+	if len(sent) != 1 || sent[0] != "first" {
+		t.Errorf("expected the canceled alert not to be sent, got %v", sent)
+	}
+	if cancelErr == nil {
+		t.Error("Cancel() expected error for an alert being sent, got nil")
+	}
+}
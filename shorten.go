@@ -0,0 +1,214 @@
+package notifox
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// DefaultShortenEllipsis marks text removed by ShortenSMS.
+const DefaultShortenEllipsis = "..."
+
+// ShortenOptions configures ShortenSMS.
+type ShortenOptions struct {
+	// MaxParts is the number of SMS parts to fit the message into.
+	MaxParts int
+	// Transliterate replaces characters that force UCS-2 encoding, such as smart
+	// quotes and en dashes, with GSM-7 equivalents from the default tables (see
+	// NewTransliterator).
+	Transliterate bool
+	// KeepFields are the "Key: value" lines kept in preference to other lines,
+	// matched case-insensitively. If empty, all such lines are preferred.
+	KeepFields []string
+	// Ellipsis marks removed text. Defaults to DefaultShortenEllipsis.
+	Ellipsis string
+	// Link, if set, is appended to a shortened message, e.g. a dashboard URL.
+	Link string
+}
+
+// ShortenResult is the outcome of ShortenSMS.
+type ShortenResult struct {
+	Text     string
+	Parts    int
+	Encoding string
+	// Shortened reports whether any text was removed.
+	Shortened bool
+	// Transliterated reports whether any characters were replaced.
+	Transliterated bool
+}
+
+// fieldLine matches a "Key: value" line.
+var fieldLine = regexp.MustCompile(`^([A-Za-z][\w .-]{0,30}):\s+\S`)
+
+// ShortenSMS fits text into opts.MaxParts SMS parts, using the same encoding rules
+// as CalculateParts. Whitespace is collapsed and, if allowed, characters are
+// transliterated first. If the message is still too long, the first line and
+// key fields are kept, other lines are kept in order while they fit, each cut is
+// marked with the ellipsis, and the link is appended. The link is dropped if it
+// leaves no room for the first line.
+func ShortenSMS(text string, opts ShortenOptions) ShortenResult {
+	if opts.Ellipsis == "" {
+		opts.Ellipsis = DefaultShortenEllipsis
+	}
+
+	var result ShortenResult
+	text = collapseWhitespace(text)
+	if opts.Transliterate {
+		t := NewTransliterator().Transliterate(text)
+		text, result.Transliterated = t.Text, t.Replaced
+	}
+
+	if opts.MaxParts > 0 && smsParts(text) > opts.MaxParts {
+		text = shortenLines(strings.Split(text, "\n"), opts)
+		result.Shortened = true
+	}
+
+	result.Text = text
+	result.Parts = smsParts(text)
+	result.Encoding = smsEncoding(text)
+	return result
+}
+
+// shortenLines keeps the first line and key fields, then other lines while they
+// fit. The ellipsis marks each place text was cut and the link goes at the end.
+func shortenLines(lines []string, opts ShortenOptions) string {
+	var tail string
+	if opts.Link != "" {
+		tail = " " + opts.Link
+	}
+	fits := func(s string) bool {
+		return smsParts(s) <= opts.MaxParts
+	}
+
+	keep := make([]bool, len(lines))
+	keep[0] = true
+	for i, line := range lines[1:] {
+		keep[i+1] = isKeyField(line, opts.KeepFields)
+	}
+	// partial is the line cut short, if any. It ends with the ellipsis, which
+	// then also marks the lines dropped after it.
+	partial := -1
+	join := func() string {
+		var out []string
+		gap, marked := false, false
+		for i, line := range lines {
+			if !keep[i] {
+				gap = true
+				continue
+			}
+			if gap && !marked {
+				out = append(out, opts.Ellipsis)
+			}
+			out = append(out, line)
+			gap, marked = false, i == partial
+		}
+		if gap && !marked {
+			out = append(out, opts.Ellipsis)
+		}
+		return strings.Join(out, "\n")
+	}
+
+	// If the preferred lines alone don't fit, cut them.
+	if !fits(join() + tail) {
+		text := join()
+		cut := func(tail string) string {
+			return fitPrefix(text, func(prefix string) bool {
+				return fits(prefix + opts.Ellipsis + tail)
+			})
+		}
+		head := cut(tail)
+		if head == "" && tail != "" {
+			// The link leaves no room for the first line, so drop it.
+			tail = ""
+			head = cut(tail)
+		}
+		if head == "" {
+			// Not even the ellipsis fits.
+			return fitPrefix(text, fits)
+		}
+		return head + opts.Ellipsis + tail
+	}
+
+	for i, line := range lines {
+		if keep[i] {
+			continue
+		}
+		keep[i] = true
+		if fits(join() + tail) {
+			continue
+		}
+
+		// Keep as much of the first line that doesn't fit as possible, then stop.
+		partial = i
+		prefix := fitPrefix(line, func(prefix string) bool {
+			lines[i] = prefix + opts.Ellipsis
+			return fits(join() + tail)
+		})
+		if prefix == "" {
+			keep[i] = false
+			partial = -1
+			break
+		}
+		lines[i] = prefix + opts.Ellipsis
+		break
+	}
+
+	return join() + tail
+}
+
+// isKeyField reports whether line is a "Key: value" line to prefer.
+func isKeyField(line string, fields []string) bool {
+	m := fieldLine.FindStringSubmatch(line)
+	if m == nil {
+		return false
+	}
+	if len(fields) == 0 {
+		return true
+	}
+	for _, f := range fields {
+		if strings.EqualFold(strings.TrimSpace(m[1]), f) {
+			return true
+		}
+	}
+	return false
+}
+
+// fitPrefix returns the longest prefix of s for which fits is true, cut at a
+// word boundary where possible.
+func fitPrefix(s string, fits func(prefix string) bool) string {
+	runes := []rune(s)
+	lo, hi := 0, len(runes)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if fits(string(runes[:mid])) {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+
+	cut := lo
+	if cut < len(runes) {
+		// Back up to the last space, unless that would lose too much.
+		for i := cut; i > 0 && cut-i < 20; i-- {
+			if unicode.IsSpace(runes[i]) {
+				cut = i
+				break
+			}
+		}
+	}
+	return strings.TrimRightFunc(string(runes[:cut]), unicode.IsSpace)
+}
+
+// collapseWhitespace trims lines, collapses runs of spaces and tabs and drops
+// empty lines.
+func collapseWhitespace(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,122 @@
+package notifox
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestShortenSMS(t *testing.T) {
+	t.Run("fits unchanged", func(t *testing.T) {
+		got := ShortenSMS("Disk   full\n\n  on db-1 ", ShortenOptions{MaxParts: 1})
+		if got.Text != "Disk full\non db-1" || got.Shortened || got.Parts != 1 {
+			t.Errorf("ShortenSMS() = %+v", got)
+		}
+	})
+
+	t.Run("transliterates", func(t *testing.T) {
+		text := "Can’t reach “db-1” – retrying…"
+		if got := ShortenSMS(text, ShortenOptions{MaxParts: 1}); got.Encoding != EncodingUCS2 {
+			t.Errorf("Encoding = %s, want %s", got.Encoding, EncodingUCS2)
+		}
+		got := ShortenSMS(text, ShortenOptions{MaxParts: 1, Transliterate: true})
+		if got.Text != `Can't reach "db-1" - retrying...` || got.Encoding != EncodingGSM7 || !got.Transliterated {
+			t.Errorf("ShortenSMS() = %+v", got)
+		}
+		got = ShortenSMS("🚨 Can’t reach db-1", ShortenOptions{MaxParts: 1, Transliterate: true})
+		if got.Text != "🚨 Can't reach db-1" || !got.Transliterated {
+			t.Errorf("ShortenSMS() = %+v, want mapped characters replaced", got)
+		}
+	})
+
+	t.Run("keeps first line and key fields", func(t *testing.T) {
+		text := strings.Join([]string{
+			"CRITICAL: checkout latency",
+			strings.Repeat("stack frame ", 30),
+			"Host: web-3",
+			"Status: degraded",
+			"Region: eu-west-1",
+		}, "\n")
+		got := ShortenSMS(text, ShortenOptions{MaxParts: 1, KeepFields: []string{"host", "status"}, Link: "https://x.co/1"})
+		if got.Parts != 1 || !got.Shortened {
+			t.Fatalf("ShortenSMS() = %+v", got)
+		}
+		for _, want := range []string{"CRITICAL: checkout latency", "Host: web-3", "Status: degraded"} {
+			if !strings.Contains(got.Text, want) {
+				t.Errorf("ShortenSMS() = %q, missing %q", got.Text, want)
+			}
+		}
+		if !strings.Contains(got.Text, "stack...\nHost: web-3") || !strings.HasSuffix(got.Text, "\n... https://x.co/1") {
+			t.Errorf("ShortenSMS() = %q, want cuts marked and link appended", got.Text)
+		}
+	})
+
+	t.Run("marks lines dropped between key fields", func(t *testing.T) {
+		// The kept lines and the marker fill exactly one part.
+		first := "CRITICAL: " + strings.Repeat("x", 117)
+		text := strings.Join([]string{first, "Host: web-3", "Disk: 91%", "Status: degraded"}, "\n")
+		got := ShortenSMS(text, ShortenOptions{MaxParts: 1, KeepFields: []string{"host", "status"}})
+		want := first + "\nHost: web-3\n...\nStatus: degraded"
+		if got.Text != want {
+			t.Errorf("ShortenSMS() = %q, want %q", got.Text, want)
+		}
+	})
+
+	t.Run("drops link that leaves no room", func(t *testing.T) {
+		link := "https://example.com/" + strings.Repeat("a", 160)
+		got := ShortenSMS(strings.Repeat("word ", 100), ShortenOptions{MaxParts: 1, Link: link})
+		if got.Parts != 1 || strings.Contains(got.Text, link) || !strings.HasPrefix(got.Text, "word word") {
+			t.Errorf("ShortenSMS() = %+v", got)
+		}
+	})
+
+	t.Run("cuts single line at word boundary", func(t *testing.T) {
+		got := ShortenSMS(strings.Repeat("word ", 100), ShortenOptions{MaxParts: 1})
+		if got.Parts != 1 || !strings.HasSuffix(got.Text, "word...") {
+			t.Errorf("ShortenSMS() = %+v", got)
+		}
+	})
+
+	t.Run("counts UCS-2 parts", func(t *testing.T) {
+		got := ShortenSMS(strings.Repeat("日本語 ", 60), ShortenOptions{MaxParts: 2})
+		if got.Parts != 2 || got.Encoding != EncodingUCS2 {
+			t.Errorf("ShortenSMS() = %+v", got)
+		}
+	})
+}
+
+func TestSendAlertShorten(t *testing.T) {
+	var sent []AlertRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req AlertRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		sent = append(sent, req)
+		json.NewEncoder(w).Encode(AlertResponse{MessageID: "msg-1"})
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	long := strings.Repeat("too long ", 50)
+	resp, err := client.SendAlert(context.Background(), AlertRequest{Audience: "oncall", Alert: long, Channel: SMS, Shorten: &ShortenOptions{MaxParts: 1}})
+	if err != nil {
+		t.Fatalf("SendAlert() unexpected error: %v", err)
+	}
+	if !resp.Shortened || smsParts(sent[0].Alert) != 1 {
+		t.Errorf("alert not shortened: %q", sent[0].Alert)
+	}
+
+	resp, err = client.SendAlert(context.Background(), AlertRequest{Audience: "oncall", Alert: long, Channel: Email, Shorten: &ShortenOptions{MaxParts: 1}})
+	if err != nil {
+		t.Fatalf("SendAlert() unexpected error: %v", err)
+	}
+	if resp.Shortened || sent[1].Alert != long {
+		t.Error("email alert should not be shortened")
+	}
+}
@@ -0,0 +1,108 @@
+package notifox
+
+import "strings"
+
+// SMS encodings, as reported in AlertResponse.Encoding and PartsResponse.Encoding.
+const (
+	EncodingGSM7 = "GSM-7"
+	EncodingUCS2 = "UCS-2"
+)
+
+// Characters per SMS part. Multi-part messages lose room to the concatenation header.
+const (
+	gsm7SinglePart = 160
+	gsm7MultiPart  = 153
+	ucs2SinglePart = 70
+	ucs2MultiPart  = 67
+)
+
+// gsm7Basic is the GSM 03.38 basic character set.
+const gsm7Basic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+	"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+
+// gsm7Extended characters are sent as an escape plus a character, so count twice.
+const gsm7Extended = "^{}\\[~]|€\f"
+
+// isGSM7 reports whether r can be sent without switching the message to UCS-2.
+func isGSM7(r rune) bool {
+	return strings.ContainsRune(gsm7Basic, r) || strings.ContainsRune(gsm7Extended, r)
+}
+
+// smsEncoding returns the encoding an SMS carrying s would use.
+func smsEncoding(s string) string {
+	for _, r := range s {
+		if !isGSM7(r) {
+			return EncodingUCS2
+		}
+	}
+	return EncodingGSM7
+}
+
+// smsLength returns the number of encoded characters in s and the encoding used.
+func smsLength(s string) (int, string) {
+	encoding := smsEncoding(s)
+
+	n := 0
+	for _, r := range s {
+		switch {
+		case encoding == EncodingUCS2 && r > 0xFFFF:
+			// Characters outside the BMP take a UTF-16 surrogate pair.
+			n += 2
+		case encoding == EncodingGSM7 && strings.ContainsRune(gsm7Extended, r):
+			n += 2
+		default:
+			n++
+		}
+	}
+	return n, encoding
+}
+
+// smsParts returns the number of SMS parts needed to send s.
+func smsParts(s string) int {
+	n, encoding := smsLength(s)
+
+	single, multi := gsm7SinglePart, gsm7MultiPart
+	if encoding == EncodingUCS2 {
+		single, multi = ucs2SinglePart, ucs2MultiPart
+	}
+
+	if n == 0 {
+		return 0
+	}
+	if n <= single {
+		return 1
+	}
+	return (n + multi - 1) / multi
+}
+
+// fitTail returns head followed by the longest suffix of tail that keeps the
+// message within maxParts SMS parts. A cut tail is marked with a leading "...";
+// if head alone is too long, it is cut and marked with a trailing "...".
+func fitTail(head, tail string, maxParts int) string {
+	if maxParts <= 0 || smsParts(head+tail) <= maxParts {
+		return head + tail
+	}
+
+	runes := []rune(tail)
+	// Binary search for the longest suffix that fits.
+	lo, hi := 0, len(runes)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if smsParts(head+"..."+string(runes[len(runes)-mid:])) <= maxParts {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+
+	if lo == 0 {
+		if smsParts(head) > maxParts {
+			head = strings.TrimRight(head, "\n")
+			return fitPrefix(head, func(prefix string) bool {
+				return smsParts(prefix+"...") <= maxParts
+			}) + "..."
+		}
+		return head
+	}
+	return head + "..." + string(runes[len(runes)-lo:])
+}
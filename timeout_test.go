@@ -0,0 +1,143 @@
+package notifox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newSlowServer returns a server that answers the first slowAttempts requests
+// after delay and the rest immediately.
+func newSlowServer(t *testing.T, slowAttempts int32, delay time.Duration) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Read the body so the server notices when the client gives up.
+		io.Copy(io.Discard, r.Body)
+		if requests.Add(1) <= slowAttempts {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		json.NewEncoder(w).Encode(AlertResponse{MessageID: "msg-1"})
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestAttemptTimeoutRetries(t *testing.T) {
+	server, requests := newSlowServer(t, 1, time.Second)
+
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL), WithAttemptTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	start := time.Now()
+	resp, err := client.SendAlert(context.Background(), AlertRequest{Audience: "oncall", Alert: "slow"})
+	if err != nil {
+		t.Fatalf("SendAlert() unexpected error: %v", err)
+	}
+	if resp.MessageID != "msg-1" || requests.Load() != 2 {
+		t.Errorf("expected success on the second attempt, got %d requests", requests.Load())
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("slow attempt was not abandoned: took %v", elapsed)
+	}
+}
+
+func TestAttemptTimeoutExhausted(t *testing.T) {
+	server, requests := newSlowServer(t, 100, time.Second)
+
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL),
+		WithAttemptTimeout(20*time.Millisecond), WithMaxRetries(1))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	_, err = client.SendAlert(context.Background(), AlertRequest{Audience: "oncall", Alert: "slow"})
+	var connErr *NotifoxConnectionError
+	if !errors.As(err, &connErr) {
+		t.Fatalf("expected NotifoxConnectionError, got %T: %v", err, err)
+	}
+	if connErr.Reason != ReasonAttemptTimeout || connErr.Attempts != 2 || requests.Load() != 2 {
+		t.Errorf("unexpected error %v: reason %q, %d attempts, %d requests", err, connErr.Reason, connErr.Attempts, requests.Load())
+	}
+}
+
+func TestTotalTimeout(t *testing.T) {
+	server, _ := newSlowServer(t, 100, time.Second)
+
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL),
+		WithAttemptTimeout(100*time.Millisecond), WithTotalTimeout(250*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	start := time.Now()
+	_, err = client.SendAlert(context.Background(), AlertRequest{Audience: "oncall", Alert: "slow"})
+	var connErr *NotifoxConnectionError
+	if !errors.As(err, &connErr) || connErr.Reason != ReasonTotalTimeout {
+		t.Fatalf("expected total timeout, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected error to wrap context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 600*time.Millisecond {
+		t.Errorf("total timeout not enforced: took %v", elapsed)
+	}
+}
+
+func TestCallerDeadline(t *testing.T) {
+	server, requests := newSlowServer(t, 100, time.Second)
+
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL), WithTotalTimeout(time.Minute))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = client.SendAlert(ctx, AlertRequest{Audience: "oncall", Alert: "slow"})
+	var connErr *NotifoxConnectionError
+	if !errors.As(err, &connErr) || connErr.Reason != ReasonDeadline {
+		t.Fatalf("expected caller deadline, got %v", err)
+	}
+	if IsRetryable(err) || requests.Load() != 1 {
+		t.Errorf("expected no retry after the caller's deadline, got %d requests", requests.Load())
+	}
+}
+
+func TestCancelDuringBackoff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL), WithMaxRetries(10))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(150*time.Millisecond, cancel)
+
+	_, err = client.SendAlert(ctx, AlertRequest{Audience: "oncall", Alert: "down"})
+	var connErr *NotifoxConnectionError
+	if !errors.As(err, &connErr) || connErr.Reason != ReasonCanceled {
+		t.Fatalf("expected canceled during backoff, got %v", err)
+	}
+	if !errors.Is(err, context.Canceled) || connErr.Attempts < 1 {
+		t.Errorf("unexpected error %v with %d attempts", err, connErr.Attempts)
+	}
+}
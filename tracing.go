@@ -0,0 +1,93 @@
+package notifox
+
+import (
+	"context"
+	"fmt"
+)
+
+// TraceParentHeader is the W3C Trace Context header set on requests.
+const TraceParentHeader = "traceparent"
+
+// Tracer starts spans. It is a minimal interface so any tracing library, such as
+// OpenTelemetry, can be plugged in with a small adapter and without this package
+// depending on it.
+//
+// The client starts a "notifox.SendAlert" span around each SendAlert and a
+// "notifox.http" span around each HTTP attempt within it (and within other API
+// calls). Span attributes:
+//
+//   - notifox.audience, notifox.channel: the alert's audience and channel
+//   - notifox.message_id, notifox.parts, notifox.cost, notifox.currency: from the response
+//   - notifox.attempts: the number of HTTP attempts made
+//   - http.request.method, url.full, http.response.status_code: on HTTP attempt spans
+//   - notifox.hedged: true on the hedged attempt (see WithHedging)
+type Tracer interface {
+	// Start starts a span as a child of any span in ctx and returns a context
+	// containing the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a unit of work started by a Tracer.
+type Span interface {
+	SetAttributes(attrs ...Attribute)
+	RecordError(err error)
+	End()
+	// TraceParent returns the span's W3C traceparent header value
+	// ("00-<trace-id>-<span-id>-<flags>"), or "" to not propagate it.
+	TraceParent() string
+}
+
+// Attribute is a span attribute. Value is a string, bool, int, int64 or float64.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// Attr returns an Attribute.
+func Attr(key string, value any) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// WithTracer sets the tracer the client reports spans to.
+func WithTracer(t Tracer) ClientOption {
+	return func(c *Client) {
+		c.tracer = t
+	}
+}
+
+// startSpan starts a span with the client's tracer. Without one it returns a span
+// that does nothing.
+func (c *Client) startSpan(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	if c.tracer == nil {
+		return ctx, noopSpan{}
+	}
+	ctx, span := c.tracer.Start(ctx, name)
+	if len(attrs) > 0 {
+		span.SetAttributes(attrs...)
+	}
+	return ctx, span
+}
+
+// endSpan records err, if any, and ends span.
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+// FormatTraceParent formats a W3C traceparent header value, for Span implementations.
+func FormatTraceParent(traceID [16]byte, spanID [8]byte, sampled bool) string {
+	flags := 0
+	if sampled {
+		flags = 1
+	}
+	return fmt.Sprintf("00-%x-%x-%02x", traceID, spanID, flags)
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttributes(...Attribute) {}
+func (noopSpan) RecordError(error)          {}
+func (noopSpan) End()                       {}
+func (noopSpan) TraceParent() string        { return "" }
@@ -0,0 +1,164 @@
+package notifox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingTracer records finished spans.
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordingSpan
+	next  byte
+}
+
+type recordingSpan struct {
+	name   string
+	parent *recordingSpan
+	id     [8]byte
+	attrs  map[string]any
+	err    error
+	ended  bool
+}
+
+type spanKey struct{}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.next++
+	parent, _ := ctx.Value(spanKey{}).(*recordingSpan)
+	span := &recordingSpan{name: name, parent: parent, id: [8]byte{7: t.next}, attrs: map[string]any{}}
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func (s *recordingSpan) SetAttributes(attrs ...Attribute) {
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+}
+func (s *recordingSpan) RecordError(err error) { s.err = err }
+func (s *recordingSpan) End()                  { s.ended = true }
+func (s *recordingSpan) TraceParent() string {
+	return FormatTraceParent([16]byte{15: 1}, s.id, true)
+}
+
+func TestTracing(t *testing.T) {
+	var traceparents []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents = append(traceparents, r.Header.Get(TraceParentHeader))
+		if len(traceparents) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		json.NewEncoder(w).Encode(AlertResponse{MessageID: "msg-1", Parts: 1, Cost: 0.025, Currency: "USD"})
+	}))
+	defer server.Close()
+
+	tracer := &recordingTracer{}
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL), WithTracer(tracer))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	if _, err := client.SendAlert(context.Background(), AlertRequest{Audience: "oncall", Channel: SMS, Alert: "traced"}); err != nil {
+		t.Fatalf("SendAlert() unexpected error: %v", err)
+	}
+
+	if len(tracer.spans) != 3 {
+		t.Fatalf("expected 1 send span and 2 attempt spans, got %d", len(tracer.spans))
+	}
+	send, first, second := tracer.spans[0], tracer.spans[1], tracer.spans[2]
+
+	if send.name != "notifox.SendAlert" || !send.ended || send.err != nil {
+		t.Errorf("unexpected send span %+v", send)
+	}
+	for key, want := range map[string]any{
+		"notifox.audience": "oncall", "notifox.channel": "sms", "notifox.attempts": 2,
+		"notifox.message_id": "msg-1", "notifox.parts": 1, "notifox.cost": 0.025,
+	} {
+		if send.attrs[key] != want {
+			t.Errorf("send span %s = %v, want %v", key, send.attrs[key], want)
+		}
+	}
+
+	if first.parent != send || first.attrs["http.response.status_code"] != http.StatusBadGateway || first.err == nil {
+		t.Errorf("unexpected first attempt span %+v", first)
+	}
+	if second.parent != send || second.attrs["http.response.status_code"] != http.StatusOK || second.err != nil {
+		t.Errorf("unexpected second attempt span %+v", second)
+	}
+
+	if traceparents[0] != first.TraceParent() || traceparents[1] != second.TraceParent() {
+		t.Errorf("traceparent headers %v do not match the attempt spans", traceparents)
+	}
+	if !strings.HasPrefix(traceparents[0], "00-00000000000000000000000000000001-") || !strings.HasSuffix(traceparents[0], "-01") {
+		t.Errorf("malformed traceparent %q", traceparents[0])
+	}
+}
+
+func TestTracingFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusPaymentRequired)
+	}))
+	defer server.Close()
+
+	tracer := &recordingTracer{}
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL), WithTracer(tracer))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	if _, err := client.SendAlert(context.Background(), AlertRequest{Audience: "oncall", Alert: "traced"}); err == nil {
+		t.Fatal("SendAlert() expected error, got nil")
+	}
+
+	send := tracer.spans[0]
+	if send.attrs["http.response.status_code"] != http.StatusPaymentRequired || send.attrs["notifox.attempts"] != 1 || send.err == nil {
+		t.Errorf("unexpected send span %+v", send)
+	}
+}
+
+// logTracer is an example Tracer that logs spans. An OpenTelemetry adapter has
+// the same shape: Start calls otel's tracer.Start, SetAttributes converts each
+// Attribute to an attribute.KeyValue, and TraceParent formats the span context.
+type logTracer struct{}
+
+type logSpan struct {
+	name  string
+	attrs []Attribute
+}
+
+func (logTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	return ctx, &logSpan{name: name}
+}
+
+func (s *logSpan) SetAttributes(attrs ...Attribute) { s.attrs = append(s.attrs, attrs...) }
+func (s *logSpan) RecordError(err error)            { s.attrs = append(s.attrs, Attr("error", err.Error())) }
+func (s *logSpan) TraceParent() string              { return "" }
+func (s *logSpan) End() {
+	var b strings.Builder
+	for _, a := range s.attrs {
+		fmt.Fprintf(&b, " %s=%v", a.Key, a.Value)
+	}
+	log.Printf("span %s%s", s.name, b.String())
+}
+
+func ExampleWithTracer() {
+	client, err := NewClientWithOptions(WithTracer(logTracer{}))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Logs a notifox.http span per attempt and a notifox.SendAlert span.
+	client.SendAlert(context.Background(), AlertRequest{Audience: "oncall", Alert: "Deploy finished"})
+}
@@ -0,0 +1,177 @@
+package notifox
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Transliterator replaces characters that would switch an SMS to UCS-2 encoding
+// with GSM-7 equivalents, e.g. ’ with ' and ê with e.
+type Transliterator struct {
+	// AllOrNothing leaves the text unchanged if any character has no mapping,
+	// since it is sent as UCS-2 anyway. By default mapped characters are still
+	// replaced.
+	AllOrNothing bool
+
+	table map[rune]string
+}
+
+// TransliterationResult is the outcome of Transliterator.Transliterate.
+type TransliterationResult struct {
+	Text     string
+	Encoding string
+	// Forced lists the distinct characters that forced UCS-2 before transliteration.
+	Forced []string
+	// Unmapped lists the characters that still force UCS-2 because no table maps
+	// them. If any remain and AllOrNothing is set, Text is unchanged.
+	Unmapped []string
+	// Replaced reports whether any characters were replaced.
+	Replaced bool
+}
+
+// PunctuationTable maps Unicode quotes, dashes, spaces and other punctuation.
+func PunctuationTable() map[rune]string {
+	return map[rune]string{
+		'‘': "'", '’': "'", '‚': "'", '‛': "'", '′': "'", '`': "'",
+		'“': `"`, '”': `"`, '„': `"`, '‟': `"`, '″': `"`, '«': `"`, '»': `"`,
+		'‹': "<", '›': ">",
+		'‐': "-", '‑': "-", '‒': "-", '–': "-", '—': "-", '―': "-", '−': "-",
+		'…': "...", '•': "*", '·': ".", '⁄': "/",
+		'\t': " ", '\u00a0': " ", '\u2002': " ", '\u2003': " ", '\u2009': " ", '\u202f': " ",
+		'\u200b': "", '\u2060': "", '\ufeff': "",
+	}
+}
+
+// LatinTable maps accented Latin letters outside GSM-7 to unaccented ones.
+func LatinTable() map[rune]string {
+	lower := map[rune]string{
+		'á': "a", 'â': "a", 'ã': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+		'ç': "c", 'ć': "c", 'ĉ': "c", 'ċ': "c", 'č': "c",
+		'ď': "d", 'đ': "d", 'ð': "d",
+		'ê': "e", 'ë': "e", 'ē': "e", 'ĕ': "e", 'ė': "e", 'ę': "e", 'ě': "e",
+		'ĝ': "g", 'ğ': "g", 'ġ': "g", 'ģ': "g",
+		'ĥ': "h", 'ħ': "h",
+		'í': "i", 'î': "i", 'ï': "i", 'ĩ': "i", 'ī': "i", 'ĭ': "i", 'į': "i", 'ı': "i",
+		'ĵ': "j", 'ķ': "k",
+		'ĺ': "l", 'ļ': "l", 'ľ': "l", 'ŀ': "l", 'ł': "l",
+		'ń': "n", 'ņ': "n", 'ň': "n",
+		'ó': "o", 'ô': "o", 'õ': "o", 'ō': "o", 'ŏ': "o", 'ő': "o", 'œ': "oe",
+		'ŕ': "r", 'ŗ': "r", 'ř': "r",
+		'ś': "s", 'ŝ': "s", 'ş': "s", 'š': "s", 'ș': "s",
+		'ţ': "t", 'ť': "t", 'ŧ': "t", 'ț': "t", 'þ': "th",
+		'ú': "u", 'û': "u", 'ũ': "u", 'ū': "u", 'ŭ': "u", 'ů': "u", 'ű': "u", 'ų': "u",
+		'ŵ': "w", 'ý': "y", 'ÿ': "y", 'ŷ': "y",
+		'ź': "z", 'ż': "z", 'ž': "z",
+	}
+
+	table := make(map[rune]string, 2*len(lower))
+	for r, s := range lower {
+		table[r] = s
+		if upper := unicode.ToUpper(r); upper != r {
+			table[upper] = strings.ToUpper(s)
+		}
+	}
+	return table
+}
+
+// SymbolTable maps common symbols such as arrows, ©, ™ and ≥.
+func SymbolTable() map[rune]string {
+	return map[rune]string{
+		'©': "(c)", '®': "(R)", '™': "TM", '°': " deg", 'µ': "u",
+		'±': "+/-", '×': "x", '÷': "/", '≤': "<=", '≥': ">=", '≠': "!=", '≈': "~",
+		'½': "1/2", '¼': "1/4", '¾': "3/4",
+		'→': "->", '←': "<-", '↑': "^", '↓': "v", '⇒': "=>", '↔': "<->",
+		'✓': "v", '✔': "v", '✗': "x", '✘': "x", '¢': "c",
+	}
+}
+
+// EmojiTable maps common alerting emoji to their shortcodes, e.g. 🚨 to
+// ":rotating_light:". It is not one of the default tables.
+func EmojiTable() map[rune]string {
+	return map[rune]string{
+		'🚨': ":rotating_light:", '🔥': ":fire:", '⚠': ":warning:", '🆘': ":sos:",
+		'✅': ":white_check_mark:", '❌': ":x:", '❗': ":exclamation:", '❓': ":question:",
+		'🔴': ":red_circle:", '🟠': ":orange_circle:", '🟡': ":yellow_circle:", '🟢': ":green_circle:",
+		'📈': ":chart_with_upwards_trend:", '📉': ":chart_with_downwards_trend:",
+		'🚀': ":rocket:", '💥': ":boom:", '💀': ":skull:", '🐛': ":bug:", '👀': ":eyes:",
+		'⏰': ":alarm_clock:", '🔔': ":bell:", '🔕': ":no_bell:", '🔒': ":lock:",
+		'👍': ":+1:", '👎': ":-1:", '🎉': ":tada:",
+		// Variation selectors and joiners that follow emoji.
+		'\ufe0f': "", '\u200d': "",
+	}
+}
+
+// DefaultTransliterationTables returns the punctuation, Latin and symbol tables.
+func DefaultTransliterationTables() []map[rune]string {
+	return []map[rune]string{PunctuationTable(), LatinTable(), SymbolTable()}
+}
+
+// NewTransliterator creates a transliterator from tables, with later tables
+// overriding earlier ones. With no tables it uses DefaultTransliterationTables.
+// Characters already in GSM-7 are never replaced.
+func NewTransliterator(tables ...map[rune]string) *Transliterator {
+	if len(tables) == 0 {
+		tables = DefaultTransliterationTables()
+	}
+	t := &Transliterator{table: make(map[rune]string)}
+	for _, table := range tables {
+		for r, s := range table {
+			if !isGSM7(r) {
+				t.table[r] = s
+			}
+		}
+	}
+	return t
+}
+
+// Transliterate replaces characters outside GSM-7 and reports which characters
+// forced UCS-2.
+func (t *Transliterator) Transliterate(s string) TransliterationResult {
+	result := TransliterationResult{Forced: UCS2Characters(s)}
+	if len(result.Forced) == 0 {
+		result.Text, result.Encoding = s, EncodingGSM7
+		return result
+	}
+
+	var b strings.Builder
+	seen := make(map[rune]bool)
+	for _, r := range s {
+		if isGSM7(r) {
+			b.WriteRune(r)
+			continue
+		}
+		if repl, ok := t.table[r]; ok {
+			b.WriteString(repl)
+			continue
+		}
+		if !seen[r] {
+			seen[r] = true
+			result.Unmapped = append(result.Unmapped, string(r))
+		}
+		b.WriteRune(r)
+	}
+
+	result.Text, result.Encoding = b.String(), EncodingGSM7
+	if len(result.Unmapped) > 0 {
+		result.Encoding = EncodingUCS2
+		if t.AllOrNothing {
+			result.Text = s
+		}
+	}
+	result.Replaced = result.Text != s
+	return result
+}
+
+// UCS2Characters returns the distinct characters in s that force UCS-2 encoding,
+// in order of appearance.
+func UCS2Characters(s string) []string {
+	var out []string
+	seen := make(map[rune]bool)
+	for _, r := range s {
+		if !isGSM7(r) && !seen[r] {
+			seen[r] = true
+			out = append(out, string(r))
+		}
+	}
+	return out
+}
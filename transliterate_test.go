@@ -0,0 +1,142 @@
+package notifox
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTransliterate(t *testing.T) {
+	tests := []struct {
+		name     string
+		tr       *Transliterator
+		text     string
+		want     string
+		encoding string
+		forced   []string
+		unmapped []string
+	}{
+		{
+			name:     "already GSM-7",
+			tr:       NewTransliterator(),
+			text:     "Café down: 50% errors",
+			want:     "Café down: 50% errors",
+			encoding: EncodingGSM7,
+		},
+		{
+			name:     "punctuation",
+			tr:       NewTransliterator(),
+			text:     "Can’t reach “db-1” — retrying…",
+			want:     `Can't reach "db-1" - retrying...`,
+			encoding: EncodingGSM7,
+			forced:   []string{"’", "“", "”", "—", "…"},
+		},
+		{
+			name:     "accented letters and symbols",
+			tr:       NewTransliterator(),
+			text:     "Łódź: CPU ≥ 95°, Ørsted © → é",
+			want:     "Lodz: CPU >= 95 deg, Ørsted (c) -> é",
+			encoding: EncodingGSM7,
+			forced:   []string{"Ł", "ó", "ź", "≥", "°", "©", "→"},
+		},
+		{
+			name:     "emoji kept without emoji table",
+			tr:       NewTransliterator(),
+			text:     "🚨 prod’s down",
+			want:     "🚨 prod's down",
+			encoding: EncodingUCS2,
+			forced:   []string{"🚨", "’"},
+			unmapped: []string{"🚨"},
+		},
+		{
+			name: "all or nothing",
+			tr: func() *Transliterator {
+				tr := NewTransliterator()
+				tr.AllOrNothing = true
+				return tr
+			}(),
+			text:     "🚨 prod’s down",
+			want:     "🚨 prod’s down",
+			encoding: EncodingUCS2,
+			forced:   []string{"🚨", "’"},
+			unmapped: []string{"🚨"},
+		},
+		{
+			name:     "emoji to text",
+			tr:       NewTransliterator(append(DefaultTransliterationTables(), EmojiTable())...),
+			text:     "🚨 prod’s down ⚠\ufe0f",
+			want:     ":rotating_light: prod's down :warning:",
+			encoding: EncodingGSM7,
+			forced:   []string{"🚨", "’", "⚠", "\ufe0f"},
+		},
+		{
+			name:     "custom table overrides",
+			tr:       NewTransliterator(PunctuationTable(), map[rune]string{'—': ", "}),
+			text:     "Disk full — db-1",
+			want:     "Disk full ,  db-1",
+			encoding: EncodingGSM7,
+			forced:   []string{"—"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.tr.Transliterate(tt.text)
+			if got.Text != tt.want || got.Encoding != tt.encoding {
+				t.Errorf("Transliterate() = %q (%s), want %q (%s)", got.Text, got.Encoding, tt.want, tt.encoding)
+			}
+			if strings.Join(got.Forced, ",") != strings.Join(tt.forced, ",") {
+				t.Errorf("Forced = %q, want %q", got.Forced, tt.forced)
+			}
+			if strings.Join(got.Unmapped, ",") != strings.Join(tt.unmapped, ",") {
+				t.Errorf("Unmapped = %q, want %q", got.Unmapped, tt.unmapped)
+			}
+			if got.Replaced != (got.Text != tt.text) {
+				t.Errorf("Replaced = %v", got.Replaced)
+			}
+		})
+	}
+}
+
+func TestSendAlertTransliterate(t *testing.T) {
+	var sent []AlertRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req AlertRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		sent = append(sent, req)
+		json.NewEncoder(w).Encode(AlertResponse{MessageID: "msg-1"})
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	resp, err := client.SendAlert(context.Background(), AlertRequest{Audience: "oncall", Alert: "It’s down", Transliterate: NewTransliterator()})
+	if err != nil {
+		t.Fatalf("SendAlert() unexpected error: %v", err)
+	}
+	if sent[0].Alert != "It's down" || len(resp.UCS2Characters) != 0 || strings.Join(resp.Transliterated, "") != "’" {
+		t.Errorf("sent %q, UCS2Characters %q, Transliterated %q", sent[0].Alert, resp.UCS2Characters, resp.Transliterated)
+	}
+
+	resp, err = client.SendAlert(context.Background(), AlertRequest{Audience: "oncall", Alert: "It’s down", Channel: Email, Transliterate: NewTransliterator()})
+	if err != nil {
+		t.Fatalf("SendAlert() unexpected error: %v", err)
+	}
+	if sent[1].Alert != "It’s down" {
+		t.Errorf("email alert should not be transliterated, sent %q", sent[1].Alert)
+	}
+
+	resp, err = client.SendAlert(context.Background(), AlertRequest{Audience: "oncall", Alert: "It’s down"})
+	if err != nil {
+		t.Fatalf("SendAlert() unexpected error: %v", err)
+	}
+	if strings.Join(resp.UCS2Characters, "") != "’" || len(resp.Transliterated) != 0 {
+		t.Errorf("UCS2Characters = %q, want [’]", resp.UCS2Characters)
+	}
+}
@@ -0,0 +1,223 @@
+package notifox
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithProxy(t *testing.T) {
+	var proxyAuth, target string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxyAuth = r.Header.Get("Proxy-Authorization")
+		target = r.URL.String()
+		json.NewEncoder(w).Encode(AlertResponse{MessageID: "via-proxy"})
+	}))
+	defer proxy.Close()
+
+	proxyURL := "http://user:secret@" + proxy.Listener.Addr().String()
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL("http://api.notifox.test"), WithProxy(proxyURL))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	resp, err := client.SendAlert(context.Background(), AlertRequest{Audience: "oncall", Alert: "proxied"})
+	if err != nil {
+		t.Fatalf("SendAlert() unexpected error: %v", err)
+	}
+	if resp.MessageID != "via-proxy" || target != "http://api.notifox.test/alert" {
+		t.Errorf("request not sent through proxy: target %q", target)
+	}
+	if want := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:secret")); proxyAuth != want {
+		t.Errorf("Proxy-Authorization = %q, want %q", proxyAuth, want)
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, clientCert := writeTestCertificate(t, dir)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			t.Error("expected a client certificate")
+		}
+		json.NewEncoder(w).Encode(AlertResponse{MessageID: "mtls"})
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := filepath.Join(dir, "ca.pem")
+	os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600)
+
+	client, err := NewClientWithOptions(
+		WithAPIKey("test-api-key"),
+		WithBaseURL(server.URL),
+		WithRootCAFile(caFile),
+		WithClientCertificate(certFile, keyFile),
+		WithConnectionPool(10, 2, 4),
+		WithHTTP2(false),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+	if tr := client.Transport(); tr.MaxConnsPerHost != 4 || tr.ForceAttemptHTTP2 {
+		t.Errorf("transport options not applied: %+v", tr)
+	}
+
+	if _, err := client.SendAlert(context.Background(), AlertRequest{Audience: "oncall", Alert: "mtls"}); err != nil {
+		t.Errorf("SendAlert() unexpected error: %v", err)
+	}
+}
+
+func TestTransportOptionsCompose(t *testing.T) {
+	custom := &http.Client{Timeout: 5 * time.Second, Transport: &http.Transport{MaxIdleConns: 7}}
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithHTTPClient(custom), WithIdleConnTimeout(time.Minute))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+
+	tr := client.Transport()
+	if tr.MaxIdleConns != 7 || tr.IdleConnTimeout != time.Minute {
+		t.Errorf("expected options applied on top of the custom transport, got %+v", tr)
+	}
+	if client.httpClient.Timeout != 5*time.Second {
+		t.Errorf("expected custom client timeout to be kept, got %v", client.httpClient.Timeout)
+	}
+	if custom.Transport.(*http.Transport).IdleConnTimeout != 0 {
+		t.Error("custom transport was modified")
+	}
+}
+
+func TestTransportOptionsDontClobber(t *testing.T) {
+	dir := t.TempDir()
+	_, _, cert := writeTestCertificate(t, dir)
+	caFile := filepath.Join(dir, "ca.pem")
+	os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0o600)
+
+	pool := x509.NewCertPool()
+	before := pool.Clone()
+	if _, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithRootCAs(pool), WithRootCAFile(caFile)); err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+	if !pool.Equal(before) {
+		t.Error("WithRootCAFile modified the pool passed to WithRootCAs")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(AlertResponse{MessageID: "msg-1"})
+	}))
+	defer server.Close()
+
+	dials := 0
+	dialer := &net.Dialer{}
+	custom := &http.Client{Transport: &http.Transport{DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dials++
+		return dialer.DialContext(ctx, network, addr)
+	}}}
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL), WithHTTPClient(custom), WithKeepAlive(time.Second))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+	if _, err := client.SendAlert(context.Background(), AlertRequest{Audience: "oncall", Alert: "dial"}); err != nil {
+		t.Fatalf("SendAlert() unexpected error: %v", err)
+	}
+	if dials != 1 {
+		t.Errorf("expected WithKeepAlive to keep the custom dialer, got %d dials", dials)
+	}
+}
+
+func TestDisableConnectionReuse(t *testing.T) {
+	var conns sync.Map
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conns.Store(r.RemoteAddr, true)
+		json.NewEncoder(w).Encode(AlertResponse{MessageID: "msg-1"})
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(WithAPIKey("test-api-key"), WithBaseURL(server.URL), WithKeepAlive(-1), WithDisableConnectionReuse())
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := client.SendAlert(context.Background(), AlertRequest{Audience: "oncall", Alert: "dial"}); err != nil {
+			t.Fatalf("SendAlert() unexpected error: %v", err)
+		}
+	}
+
+	n := 0
+	conns.Range(func(any, any) bool { n++; return true })
+	if n != 2 {
+		t.Errorf("expected a new connection per request, got %d connections", n)
+	}
+}
+
+func TestTransportOptionErrors(t *testing.T) {
+	_, err := NewClientWithOptions(
+		WithAPIKey("test-api-key"),
+		WithProxy("socks5://proxy:1080"),
+		WithRootCAFile(filepath.Join(t.TempDir(), "missing.pem")),
+	)
+	if err == nil {
+		t.Fatal("NewClientWithOptions() expected error, got nil")
+	}
+	for _, want := range []string{"invalid proxy URL", "failed to read CA file"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to mention %q, got %v", want, err)
+		}
+	}
+}
+
+// writeTestCertificate writes a self-signed client certificate and key to dir.
+func writeTestCertificate(t *testing.T, dir string) (certFile, keyFile string, cert *x509.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "notifox-test-client"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ = x509.ParseCertificate(der)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile = filepath.Join(dir, "client.pem")
+	keyFile = filepath.Join(dir, "client-key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return certFile, keyFile, cert
+}
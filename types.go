@@ -1,5 +1,7 @@
 package notifox
 
+import "time"
+
 // Channel represents the delivery channel for an alert.
 type Channel string
 
@@ -15,6 +17,20 @@ type AlertRequest struct {
 	Audience string  `json:"audience"`
 	Alert    string  `json:"alert"`
 	Channel  Channel `json:"channel"`
+	// SendAt schedules the alert for delivery at a later time.
+	SendAt *time.Time `json:"send_at,omitempty"`
+	// Delay schedules the alert for delivery after a delay. It is converted to
+	// SendAt when the alert is sent and cannot be combined with it.
+	Delay time.Duration `json:"-"`
+	// IdempotencyKey identifies the alert so that retries, hedged attempts and
+	// failover to another endpoint don't deliver it twice. Generated if empty.
+	IdempotencyKey string `json:"-"`
+	// Transliterate, if set, replaces characters that would force UCS-2 encoding
+	// in SMS alerts before they are sent. It is ignored for email.
+	Transliterate *Transliterator `json:"-"`
+	// Shorten, if set, fits SMS alerts into Shorten.MaxParts parts before they are
+	// sent (see ShortenSMS). It is ignored for email.
+	Shorten *ShortenOptions `json:"-"`
 }
 
 // AlertResponse represents the response from sending an alert.
@@ -25,6 +41,28 @@ type AlertResponse struct {
 	Currency   string  `json:"currency"`
 	Encoding   string  `json:"encoding"`
 	Characters int     `json:"characters"`
+	// ScheduledAt is set when the alert was scheduled rather than sent. MessageID
+	// then identifies the scheduled alert, for CancelScheduled.
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+	// Hedged reports that the response came from the second, hedged attempt
+	// (see WithHedging) rather than the first.
+	Hedged bool `json:"-"`
+	// Attempts is the number of requests made, including retries and hedged
+	// attempts.
+	Attempts int `json:"-"`
+	// Redactions reports what the content filter (see WithContentFilter) found in the alert.
+	Redactions []Redaction `json:"-"`
+	// IdempotencyKey is the alert's AlertRequest.IdempotencyKey, generated if it
+	// was empty. Links shortened with WithURLShortener are tied to it.
+	IdempotencyKey string `json:"-"`
+	// Shortened reports that the alert text was shortened (see AlertRequest.Shorten).
+	Shortened bool `json:"-"`
+	// UCS2Characters lists the characters that forced UCS-2 encoding of an SMS
+	// alert, after transliteration if AlertRequest.Transliterate was set.
+	UCS2Characters []string `json:"-"`
+	// Transliterated lists the characters that forced UCS-2 before
+	// AlertRequest.Transliterate was applied.
+	Transliterated []string `json:"-"`
 }
 
 // PartsRequest represents a request to calculate message parts.
@@ -44,5 +82,7 @@ type PartsResponse struct {
 
 // ErrorResponse represents an error response from the API.
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error     string `json:"error"`
+	Code      string `json:"code,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
 }
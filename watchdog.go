@@ -0,0 +1,335 @@
+package notifox
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"runtime/metrics"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultWatchdogInterval is how often a RuntimeWatchdog samples runtime metrics.
+const DefaultWatchdogInterval = 15 * time.Second
+
+// runtime/metrics names sampled by the watchdog.
+const (
+	metricGoroutines   = "/sched/goroutines:goroutines"
+	metricHeapInUse    = "/memory/classes/heap/objects:bytes"
+	metricGCPauses     = "/sched/pauses/total/gc:seconds"
+	metricSchedLatency = "/sched/latencies:seconds"
+)
+
+// Threshold is an alerting threshold with hysteresis: it fires when a value
+// reaches High and resolves once the value drops below Low.
+type Threshold struct {
+	High float64
+	// Low defaults to High when zero.
+	Low float64
+}
+
+func (t Threshold) enabled() bool {
+	return t.High > 0
+}
+
+func (t Threshold) low() float64 {
+	if t.Low <= 0 {
+		return t.High
+	}
+	return t.Low
+}
+
+// WatchdogThresholds configures when a RuntimeWatchdog fires. Zero thresholds are disabled.
+type WatchdogThresholds struct {
+	// Goroutines is the number of live goroutines.
+	Goroutines Threshold
+	// HeapInUse is the number of bytes in live and unswept heap objects.
+	HeapInUse Threshold
+	// GCPause is the 99th percentile stop-the-world GC pause over the last interval, in seconds.
+	GCPause Threshold
+	// SchedLatency is the 99th percentile time goroutines spent runnable before
+	// running over the last interval, in seconds.
+	SchedLatency Threshold
+}
+
+// RuntimeStats is a single sample of runtime metrics.
+type RuntimeStats struct {
+	Goroutines   uint64
+	HeapInUse    uint64
+	GCPause      time.Duration
+	SchedLatency time.Duration
+}
+
+// String returns a compact one-line summary of the sample.
+func (s RuntimeStats) String() string {
+	return fmt.Sprintf("goroutines %d, heap %s, gc pause p99 %s, sched latency p99 %s",
+		s.Goroutines, formatBytes(s.HeapInUse), s.GCPause, s.SchedLatency)
+}
+
+// WatchdogOption is a function that configures a RuntimeWatchdog.
+type WatchdogOption func(*RuntimeWatchdog)
+
+// WithWatchdogAudience sets the audience for watchdog alerts.
+func WithWatchdogAudience(audience string) WatchdogOption {
+	return func(w *RuntimeWatchdog) {
+		w.audience = audience
+	}
+}
+
+// WithWatchdogChannel sets the channel for watchdog alerts.
+func WithWatchdogChannel(channel Channel) WatchdogOption {
+	return func(w *RuntimeWatchdog) {
+		w.channel = channel
+	}
+}
+
+// WithWatchdogInterval sets how often runtime metrics are sampled.
+// Non-positive values use DefaultWatchdogInterval.
+func WithWatchdogInterval(interval time.Duration) WatchdogOption {
+	return func(w *RuntimeWatchdog) {
+		if interval <= 0 {
+			interval = DefaultWatchdogInterval
+		}
+		w.interval = interval
+	}
+}
+
+// WithWatchdogName sets the name used to identify the process in alerts. Defaults to the hostname.
+func WithWatchdogName(name string) WatchdogOption {
+	return func(w *RuntimeWatchdog) {
+		w.name = name
+	}
+}
+
+// WithWatchdogErrorHandler sets a function called when sending an alert fails.
+// Failed alerts are retried on the next sample.
+func WithWatchdogErrorHandler(fn func(err error)) WatchdogOption {
+	return func(w *RuntimeWatchdog) {
+		w.onError = fn
+	}
+}
+
+// RuntimeWatchdog samples Go runtime metrics (goroutines, heap in use, GC pauses
+// and scheduler latency) and alerts when they cross their thresholds, to catch
+// goroutine leaks and memory growth before they become an outage.
+type RuntimeWatchdog struct {
+	sender     AlertSender
+	thresholds WatchdogThresholds
+	audience   string
+	channel    Channel
+	interval   time.Duration
+	name       string
+	onError    func(err error)
+
+	mu      sync.Mutex
+	samples []metrics.Sample
+	// prev holds the previous histogram samples, to compute per-interval percentiles.
+	prev   map[string]*metrics.Float64Histogram
+	firing map[string]bool
+}
+
+// NewRuntimeWatchdog creates a runtime watchdog that alerts through sender.
+func NewRuntimeWatchdog(sender AlertSender, thresholds WatchdogThresholds, opts ...WatchdogOption) *RuntimeWatchdog {
+	w := &RuntimeWatchdog{
+		sender:     sender,
+		thresholds: thresholds,
+		interval:   DefaultWatchdogInterval,
+		samples: []metrics.Sample{
+			{Name: metricGoroutines},
+			{Name: metricHeapInUse},
+			{Name: metricGCPauses},
+			{Name: metricSchedLatency},
+		},
+		prev:   make(map[string]*metrics.Float64Histogram),
+		firing: make(map[string]bool),
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	if w.name == "" {
+		w.name, _ = os.Hostname()
+	}
+
+	return w
+}
+
+// Run samples runtime metrics every interval until ctx is done.
+// It returns an error straight away if no audience is set.
+func (w *RuntimeWatchdog) Run(ctx context.Context) error {
+	if w.audience == "" {
+		return fmt.Errorf("audience cannot be empty (use WithWatchdogAudience)")
+	}
+
+	// Take a baseline so the first interval's histogram percentiles are meaningful.
+	w.Sample()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			w.evaluate(ctx, w.Sample())
+		}
+	}
+}
+
+// Sample reads the current runtime metrics. Percentiles cover the time since the previous sample.
+func (w *RuntimeWatchdog) Sample() RuntimeStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	metrics.Read(w.samples)
+
+	var s RuntimeStats
+	for _, sample := range w.samples {
+		switch sample.Value.Kind() {
+		case metrics.KindUint64:
+			switch sample.Name {
+			case metricGoroutines:
+				s.Goroutines = sample.Value.Uint64()
+			case metricHeapInUse:
+				s.HeapInUse = sample.Value.Uint64()
+			}
+		case metrics.KindFloat64Histogram:
+			h := sample.Value.Float64Histogram()
+			p99 := histogramQuantile(w.prev[sample.Name], h, 0.99)
+			// The runtime reuses the histogram's memory on the next Read, so keep a copy.
+			w.prev[sample.Name] = &metrics.Float64Histogram{
+				Counts:  append([]uint64(nil), h.Counts...),
+				Buckets: h.Buckets,
+			}
+			switch sample.Name {
+			case metricGCPauses:
+				s.GCPause = secondsToDuration(p99)
+			case metricSchedLatency:
+				s.SchedLatency = secondsToDuration(p99)
+			}
+		}
+	}
+
+	return s
+}
+
+// watchdogCheck is a single threshold evaluated against a sample.
+type watchdogCheck struct {
+	name      string
+	threshold Threshold
+	value     float64
+	format    func(float64) string
+}
+
+// evaluate fires and resolves alerts for s.
+func (w *RuntimeWatchdog) evaluate(ctx context.Context, s RuntimeStats) {
+	formatDuration := func(v float64) string { return secondsToDuration(v).String() }
+	checks := []watchdogCheck{
+		{"goroutines", w.thresholds.Goroutines, float64(s.Goroutines), func(v float64) string { return fmt.Sprintf("%.0f", v) }},
+		{"heap in use", w.thresholds.HeapInUse, float64(s.HeapInUse), func(v float64) string { return formatBytes(uint64(v)) }},
+		{"gc pause p99", w.thresholds.GCPause, s.GCPause.Seconds(), formatDuration},
+		{"sched latency p99", w.thresholds.SchedLatency, s.SchedLatency.Seconds(), formatDuration},
+	}
+
+	w.mu.Lock()
+	var fired, resolved []string
+	var changed []watchdogCheck
+	for _, c := range checks {
+		if !c.threshold.enabled() {
+			continue
+		}
+		switch {
+		case !w.firing[c.name] && c.value >= c.threshold.High:
+			fired = append(fired, fmt.Sprintf("%s %s above %s", c.name, c.format(c.value), c.format(c.threshold.High)))
+			changed = append(changed, c)
+		case w.firing[c.name] && c.value < c.threshold.low():
+			resolved = append(resolved, fmt.Sprintf("%s %s back below %s", c.name, c.format(c.value), c.format(c.threshold.low())))
+			changed = append(changed, c)
+		}
+	}
+	w.mu.Unlock()
+
+	if len(changed) == 0 {
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Runtime watchdog %s: ", w.name)
+	if len(fired) > 0 {
+		b.WriteString(strings.Join(fired, "; "))
+	}
+	if len(resolved) > 0 {
+		if len(fired) > 0 {
+			b.WriteString("; ")
+		}
+		b.WriteString("resolved: " + strings.Join(resolved, "; "))
+	}
+	b.WriteString("\n" + s.String())
+
+	_, err := w.sender.SendAlert(ctx, AlertRequest{Audience: w.audience, Channel: w.channel, Alert: b.String()})
+	if err != nil {
+		if w.onError != nil {
+			w.onError(err)
+		}
+		return
+	}
+
+	w.mu.Lock()
+	for _, c := range changed {
+		w.firing[c.name] = !w.firing[c.name]
+	}
+	w.mu.Unlock()
+}
+
+// histogramQuantile returns the q-quantile of the observations added to cur since
+// prev, using bucket upper bounds. A nil prev means since process start.
+func histogramQuantile(prev, cur *metrics.Float64Histogram, q float64) float64 {
+	counts := make([]uint64, len(cur.Counts))
+	var total uint64
+	for i, n := range cur.Counts {
+		if prev != nil && i < len(prev.Counts) {
+			n -= prev.Counts[i]
+		}
+		counts[i] = n
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := uint64(math.Ceil(q * float64(total)))
+	var seen uint64
+	for i, n := range counts {
+		seen += n
+		if seen >= rank {
+			upper := cur.Buckets[i+1]
+			if math.IsInf(upper, 1) {
+				return cur.Buckets[i]
+			}
+			return upper
+		}
+	}
+	return 0
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// formatBytes formats n using binary units.
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,87 @@
+package notifox
+
+import (
+	"context"
+	"runtime/metrics"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRuntimeWatchdogHysteresis(t *testing.T) {
+	sender := &recordingSender{}
+	w := NewRuntimeWatchdog(sender, WatchdogThresholds{
+		Goroutines: Threshold{High: 1000, Low: 800},
+	}, WithWatchdogAudience("oncall"), WithWatchdogName("api-1"))
+	ctx := context.Background()
+
+	for _, n := range []uint64{500, 1200, 1500, 900} {
+		w.evaluate(ctx, RuntimeStats{Goroutines: n, HeapInUse: 64 << 20})
+	}
+	reqs := sender.requests()
+	if len(reqs) != 1 {
+		t.Fatalf("expected 1 alert while above the low threshold, got %d", len(reqs))
+	}
+	for _, want := range []string{"api-1", "goroutines 1200 above 1000", "heap 64.0MiB"} {
+		if !strings.Contains(reqs[0].Alert, want) {
+			t.Errorf("alert %q does not contain %q", reqs[0].Alert, want)
+		}
+	}
+
+	w.evaluate(ctx, RuntimeStats{Goroutines: 700})
+	reqs = sender.requests()
+	if len(reqs) != 2 {
+		t.Fatalf("expected a resolved alert, got %d alerts", len(reqs))
+	}
+	if !strings.Contains(reqs[1].Alert, "resolved: goroutines 700 back below 800") {
+		t.Errorf("unexpected resolved alert %q", reqs[1].Alert)
+	}
+}
+
+func TestRuntimeWatchdogSample(t *testing.T) {
+	w := NewRuntimeWatchdog(&recordingSender{}, WatchdogThresholds{})
+
+	s := w.Sample()
+	if s.Goroutines == 0 {
+		t.Error("expected a non-zero goroutine count")
+	}
+	if s.HeapInUse == 0 {
+		t.Error("expected a non-zero heap size")
+	}
+}
+
+func TestRuntimeWatchdogRun(t *testing.T) {
+	w := NewRuntimeWatchdog(&recordingSender{}, WatchdogThresholds{}, WithWatchdogInterval(0))
+	if err := w.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "audience") {
+		t.Errorf("Run() = %v, want audience error", err)
+	}
+
+	// A non-positive interval falls back to the default instead of panicking.
+	WithWatchdogAudience("oncall")(w)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := w.Run(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Run() = %v, want deadline exceeded", err)
+	}
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	prev := &metrics.Float64Histogram{
+		Counts:  []uint64{10, 0, 0},
+		Buckets: []float64{0, 0.001, 0.01, 0.1},
+	}
+	cur := &metrics.Float64Histogram{
+		Counts:  []uint64{60, 0, 1},
+		Buckets: prev.Buckets,
+	}
+
+	if got := histogramQuantile(prev, cur, 0.5); got != 0.001 {
+		t.Errorf("p50 = %v, want 0.001", got)
+	}
+	if got := histogramQuantile(prev, cur, 0.99); got != 0.1 {
+		t.Errorf("p99 = %v, want 0.1", got)
+	}
+	if got := secondsToDuration(histogramQuantile(cur, cur, 0.99)); got != time.Duration(0) {
+		t.Errorf("quantile with no new observations = %v, want 0", got)
+	}
+}
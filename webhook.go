@@ -0,0 +1,230 @@
+package notifox
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// WebhookSignatureHeader is the header carrying the webhook signature.
+	WebhookSignatureHeader = "Notifox-Signature"
+	// DefaultWebhookTolerance is the default maximum age of a webhook timestamp.
+	DefaultWebhookTolerance = 5 * time.Minute
+	// maxWebhookBody caps the size of webhook payloads.
+	maxWebhookBody = 1 << 20
+)
+
+// ErrInvalidSignature is returned when a webhook signature is missing, malformed,
+// does not match or is outside the timestamp tolerance.
+var ErrInvalidSignature = errors.New("notifox: invalid webhook signature")
+
+// DeliveryEvent is a delivery status callback sent by Notifox.
+type DeliveryEvent struct {
+	// ID uniquely identifies the event; retried deliveries reuse it.
+	ID        string        `json:"id"`
+	Type      string        `json:"type"`
+	MessageID string        `json:"message_id"`
+	Status    MessageStatus `json:"status"`
+	Channel   Channel       `json:"channel,omitempty"`
+	Recipient string        `json:"recipient,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	Timestamp time.Time     `json:"timestamp"`
+}
+
+// SignWebhookPayload returns the WebhookSignatureHeader value for payload signed
+// with secret at timestamp. It is useful for testing webhook receivers.
+func SignWebhookPayload(secret string, timestamp time.Time, payload []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	return fmt.Sprintf("t=%s,v1=%s", ts, webhookMAC(secret, ts, payload))
+}
+
+// VerifyWebhookSignature checks that header is a valid signature of payload made
+// with secret no more than tolerance ago. A non-positive tolerance means
+// DefaultWebhookTolerance. The error matches ErrInvalidSignature, including when
+// secret is empty, since anyone could sign with an empty secret.
+func VerifyWebhookSignature(secret, header string, payload []byte, tolerance time.Duration) error {
+	return verifyWebhookSignature(secret, header, payload, tolerance, time.Now())
+}
+
+func verifyWebhookSignature(secret, header string, payload []byte, tolerance time.Duration, now time.Time) error {
+	if secret == "" {
+		return fmt.Errorf("%w: no signing secret configured", ErrInvalidSignature)
+	}
+	if tolerance <= 0 {
+		tolerance = DefaultWebhookTolerance
+	}
+
+	var ts string
+	var sigs []string
+	for _, part := range strings.Split(header, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sigs = append(sigs, v)
+		}
+	}
+	if ts == "" || len(sigs) == 0 {
+		return fmt.Errorf("%w: missing timestamp or signature", ErrInvalidSignature)
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: malformed timestamp", ErrInvalidSignature)
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("%w: timestamp outside tolerance", ErrInvalidSignature)
+	}
+
+	expected := []byte(webhookMAC(secret, ts, payload))
+	for _, sig := range sigs {
+		if hmac.Equal([]byte(sig), expected) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: signature mismatch", ErrInvalidSignature)
+}
+
+// webhookMAC returns the hex HMAC-SHA256 of "timestamp.payload".
+func webhookMAC(secret, ts string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookOption is a function that configures a WebhookHandler.
+type WebhookOption func(*WebhookHandler)
+
+// WithWebhookTolerance sets the maximum age of a webhook timestamp, to stop replays.
+// Non-positive values use DefaultWebhookTolerance; the check can't be disabled.
+func WithWebhookTolerance(tolerance time.Duration) WebhookOption {
+	return func(h *WebhookHandler) {
+		if tolerance <= 0 {
+			tolerance = DefaultWebhookTolerance
+		}
+		h.tolerance = tolerance
+	}
+}
+
+// WithWebhookFallback sets a function called for events with no handler registered
+// for their message ID.
+func WithWebhookFallback(fn func(DeliveryEvent)) WebhookOption {
+	return func(h *WebhookHandler) {
+		h.fallback = fn
+	}
+}
+
+// WebhookHandler is an http.Handler that receives Notifox delivery status callbacks,
+// verifies their signature and dispatches them to handlers registered by message ID.
+type WebhookHandler struct {
+	secret    string
+	tolerance time.Duration
+	fallback  func(DeliveryEvent)
+	now       func() time.Time
+
+	mu       sync.Mutex
+	handlers map[string][]func(DeliveryEvent)
+}
+
+// NewWebhookHandler creates a webhook receiver that verifies callbacks with the
+// account's signing secret, which must not be empty.
+func NewWebhookHandler(secret string, opts ...WebhookOption) (*WebhookHandler, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("webhook signing secret cannot be empty")
+	}
+
+	h := &WebhookHandler{
+		secret:    secret,
+		tolerance: DefaultWebhookTolerance,
+		now:       time.Now,
+		handlers:  make(map[string][]func(DeliveryEvent)),
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h, nil
+}
+
+// Handle registers fn to be called with events for messageID. Handlers are
+// removed once a terminal (delivered or failed) event has been dispatched.
+func (h *WebhookHandler) Handle(messageID string, fn func(DeliveryEvent)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.handlers[messageID] = append(h.handlers[messageID], fn)
+}
+
+// Remove unregisters all handlers for messageID.
+func (h *WebhookHandler) Remove(messageID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.handlers, messageID)
+}
+
+// ServeHTTP verifies and decodes a callback and dispatches it. It responds 401 to
+// invalid signatures, so Notifox does not retry them, and 400 to malformed events.
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	payload, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	if err := verifyWebhookSignature(h.secret, r.Header.Get(WebhookSignatureHeader), payload, h.tolerance, h.now()); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var event DeliveryEvent
+	if err := json.Unmarshal(payload, &event); err != nil || event.MessageID == "" {
+		http.Error(w, "malformed event", http.StatusBadRequest)
+		return
+	}
+
+	h.dispatch(event)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) dispatch(event DeliveryEvent) {
+	h.mu.Lock()
+	handlers := h.handlers[event.MessageID]
+	if event.Status.Terminal() {
+		delete(h.handlers, event.MessageID)
+	}
+	h.mu.Unlock()
+
+	if len(handlers) == 0 {
+		if h.fallback != nil {
+			h.fallback(event)
+		}
+		return
+	}
+	for _, fn := range handlers {
+		fn(event)
+	}
+}
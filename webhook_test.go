@@ -0,0 +1,92 @@
+package notifox
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhookHandler(t *testing.T) {
+	const secret = "whsec_test"
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var got []DeliveryEvent
+	var unhandled int
+	h, err := NewWebhookHandler(secret, WithWebhookFallback(func(DeliveryEvent) { unhandled++ }))
+	if err != nil {
+		t.Fatalf("NewWebhookHandler() unexpected error: %v", err)
+	}
+	h.now = func() time.Time { return now }
+	h.Handle("msg-1", func(e DeliveryEvent) { got = append(got, e) })
+
+	send := func(payload string, signature string) int {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/notifox", bytes.NewBufferString(payload))
+		req.Header.Set(WebhookSignatureHeader, signature)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	sent := `{"id":"evt-1","type":"message.sent","message_id":"msg-1","status":"sent"}`
+	delivered := `{"id":"evt-2","type":"message.delivered","message_id":"msg-1","status":"delivered"}`
+
+	tests := []struct {
+		name       string
+		payload    string
+		signature  string
+		wantStatus int
+	}{
+		{name: "valid sent event", payload: sent, signature: SignWebhookPayload(secret, now, []byte(sent)), wantStatus: http.StatusNoContent},
+		{name: "wrong secret", payload: sent, signature: SignWebhookPayload("other", now, []byte(sent)), wantStatus: http.StatusUnauthorized},
+		{name: "replayed event", payload: sent, signature: SignWebhookPayload(secret, now.Add(-time.Hour), []byte(sent)), wantStatus: http.StatusUnauthorized},
+		{name: "missing signature", payload: sent, signature: "", wantStatus: http.StatusUnauthorized},
+		{name: "malformed event", payload: "{", signature: SignWebhookPayload(secret, now, []byte("{")), wantStatus: http.StatusBadRequest},
+		{name: "valid delivered event", payload: delivered, signature: SignWebhookPayload(secret, now, []byte(delivered)), wantStatus: http.StatusNoContent},
+		{name: "after terminal event", payload: sent, signature: SignWebhookPayload(secret, now, []byte(sent)), wantStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := send(tt.payload, tt.signature); status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+		})
+	}
+
+	if len(got) != 2 || got[0].Status != MessageSent || got[1].Status != MessageDelivered {
+		t.Errorf("unexpected dispatched events %+v", got)
+	}
+	if unhandled != 1 {
+		t.Errorf("expected the event after the terminal one to go to the fallback, got %d", unhandled)
+	}
+}
+
+func TestVerifyWebhookSignature(t *testing.T) {
+	payload := []byte(`{"message_id":"msg-1"}`)
+	header := SignWebhookPayload("secret", time.Now(), payload)
+
+	if err := VerifyWebhookSignature("secret", header, payload, DefaultWebhookTolerance); err != nil {
+		t.Errorf("VerifyWebhookSignature() unexpected error: %v", err)
+	}
+
+	err := VerifyWebhookSignature("secret", header, []byte(`{"message_id":"msg-2"}`), DefaultWebhookTolerance)
+	if !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature for tampered payload, got %v", err)
+	}
+
+	old := SignWebhookPayload("secret", time.Now().Add(-time.Hour), payload)
+	if err := VerifyWebhookSignature("secret", old, payload, 0); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected zero tolerance to use the default and reject an old timestamp, got %v", err)
+	}
+
+	unsigned := SignWebhookPayload("", time.Now(), payload)
+	if err := VerifyWebhookSignature("", unsigned, payload, DefaultWebhookTolerance); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature for an empty secret, got %v", err)
+	}
+	if _, err := NewWebhookHandler(""); err == nil {
+		t.Error("NewWebhookHandler() expected error for an empty secret, got nil")
+	}
+}